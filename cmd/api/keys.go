@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/antsrp/banner_service/pkg/jwt"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+// watchKeyring reloads the keyring on SIGHUP and every interval, if it is positive.
+func watchKeyring(keyring *jwt.Keyring, interval time.Duration, logger logger.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-hup:
+		case <-tick:
+		}
+		if err := keyring.Reload(); err != nil {
+			logger.Error("can't reload signing keys, keeping the old ones: %v", err.Error())
+			continue
+		}
+		logger.Debug("signing keys reloaded, active key %s of %d", keyring.ActiveID(), keyring.Size())
+	}
+}
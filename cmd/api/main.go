@@ -3,13 +3,18 @@ package main
 import (
 	"context"
 	"os"
+	"time"
 
+	"github.com/antsrp/banner_service/internal/cache"
+	"github.com/antsrp/banner_service/internal/cache/memory"
 	"github.com/antsrp/banner_service/internal/cache/redis"
+	"github.com/antsrp/banner_service/internal/cache/tiered"
 	"github.com/antsrp/banner_service/internal/domain/models"
 	"github.com/antsrp/banner_service/internal/repository/postgres"
 	"github.com/antsrp/banner_service/internal/rest"
 	"github.com/antsrp/banner_service/internal/service"
 	"github.com/antsrp/banner_service/pkg/config"
+	as "github.com/antsrp/banner_service/pkg/infrastructure/auth"
 	cs "github.com/antsrp/banner_service/pkg/infrastructure/cache"
 	ds "github.com/antsrp/banner_service/pkg/infrastructure/db"
 	rs "github.com/antsrp/banner_service/pkg/infrastructure/rest"
@@ -20,11 +25,6 @@ import (
 
 func main() {
 	var logger logger.Logger = slog.NewTextLogger(os.Stdout, slog.WithDebugLevel(), slog.WithFormat())
-	key, err := os.ReadFile(".secret")
-	if err != nil {
-		logger.Fatal("can't parse secret key: %v", err.Error())
-		return
-	}
 	if err := config.Load(); err != nil {
 		logger.Fatal("can't load env files: %v", err.Error())
 		return
@@ -43,31 +43,114 @@ func main() {
 	defer dbConn.Close()
 	ustorage := postgres.NewUserStorage(dbConn)
 	bstorage := postgres.NewBannerStorage(dbConn)
+	fstorage := postgres.NewFeatureStorage(dbConn)
+	tstorage := postgres.NewTagStorage(dbConn)
 
 	serverSettings, err := config.Parse[rs.Settings]("SERVER")
 	if err != nil {
 		logger.Fatal("can't parse http server settings from env file: %v", err.Error())
 	}
-	js := jwt.NewJwtService(key, jwt.WithMethodHS256)
+	authSettings, err := config.Parse[as.Settings]("AUTH")
+	if err != nil {
+		logger.Fatal("can't parse auth settings from env file: %v", err.Error())
+	}
+	jwtOptions := []jwt.JWTOption{jwt.WithLeeway(time.Duration(authSettings.ClockSkew) * time.Second)}
+	var key []byte
+	var keySource jwt.KeySource
+	switch {
+	case authSettings.SigningKeysDir != "":
+		keySource = jwt.DirKeySource(authSettings.SigningKeysDir)
+	case authSettings.ActiveSigningKey != "":
+		// the environment of a running process doesn't change, so this keyring is never reloaded
+		keySource = jwt.EnvKeySource("AUTH_SIGNING_KEY_", "AUTH_ACTIVE_SIGNING_KEY")
+	}
+	if keySource != nil {
+		keyring, err := jwt.NewKeyring(keySource)
+		if err != nil {
+			logger.Fatal("can't load signing keys: %v", err.Error())
+		}
+		logger.Info("signing keys loaded, active key %s of %d", keyring.ActiveID(), keyring.Size())
+		if authSettings.SigningKeysDir != "" {
+			go watchKeyring(keyring, time.Duration(authSettings.SigningKeysReload)*time.Second, logger)
+		}
+		jwtOptions = append(jwtOptions, jwt.WithKeyring(keyring))
+	} else if authSettings.PrivateKeyFile != "" {
+		privateKey, err := jwt.LoadPrivateKey(authSettings.PrivateKeyFile)
+		if err != nil {
+			logger.Fatal("can't load private key: %v", err.Error())
+		}
+		option, err := jwt.WithPrivateKey(privateKey)
+		if err != nil {
+			logger.Fatal("can't use private key: %v", err.Error())
+		}
+		jwtOptions = append(jwtOptions, option)
+	} else {
+		if key, err = os.ReadFile(".secret"); err != nil {
+			logger.Fatal("can't parse secret key: %v", err.Error())
+		}
+		jwtOptions = append(jwtOptions, jwt.WithMethodHS256)
+	}
+	js := jwt.NewJwtService(key, jwtOptions...)
 
 	cacheSettings, err := config.Parse[cs.Settings]("CACHE")
 	if err != nil {
 		logger.Fatal("can't parse cache settings from env file: %v", err.Error())
 	}
-	cacheStorage, err := redis.NewStorage[models.Banner](cacheSettings, logger)
-	if err != nil {
-		logger.Fatal("can't create redis connection: %v", err.Error())
+	var (
+		bannerCache  cache.Storager[models.Banner]
+		pointerCache cache.Storager[int64]
+	)
+	// the generation pointer must outlive any banner entry
+	pointerSettings := cacheSettings
+	pointerSettings.ExpirationTime = 0
+	switch cacheSettings.Type {
+	case "", "redis":
+		bannerCache, err = redis.NewStorage[models.Banner](cacheSettings, logger)
+		if err != nil {
+			logger.Fatal("can't create redis connection: %v", err.Error())
+		}
+		pointerCache, err = redis.NewStorage[int64](pointerSettings, logger)
+		if err != nil {
+			logger.Fatal("can't create redis connection: %v", err.Error())
+		}
+	case "memory":
+		bannerCache = memory.NewStorage[models.Banner](cacheSettings, logger)
+		pointerCache = memory.New[int64](1, 0, logger)
+	case "tiered":
+		bannerCache, err = tiered.NewStorage[models.Banner](cacheSettings, logger)
+		if err != nil {
+			logger.Fatal("can't create tiered cache: %v", err.Error())
+		}
+		pointerCache, err = redis.NewStorage[int64](pointerSettings, logger)
+		if err != nil {
+			logger.Fatal("can't create redis connection: %v", err.Error())
+		}
+	default:
+		logger.Fatal("unknown cache type %q, expected redis, memory or tiered", cacheSettings.Type)
 	}
+	cacheKeys := cache.NewKeyBuilder(cacheSettings.KeyPrefix, cacheSettings.KeyVersion)
+	cacheStorage := cache.NewGenerations(bannerCache, pointerCache, cacheKeys)
 
-	bs := service.NewBannerService(bstorage, cacheStorage, logger)
-	us := service.NewUserService(ustorage, js, logger)
-
-	handler := rest.NewHandler(serverSettings, logger, bs, us)
+	jobs := service.NewJobService(logger)
+	bs := service.NewBannerService(bstorage, fstorage, cacheStorage, cacheKeys, jobs, logger)
+	fs := service.NewFeatureService(fstorage, cacheStorage, cacheKeys, logger)
+	ts := service.NewTagService(tstorage, cacheStorage, cacheKeys, logger)
+	us := service.NewUserService(ustorage, js,
+		time.Duration(authSettings.AccessTokenTTL)*time.Second, time.Duration(authSettings.RefreshTokenTTL)*time.Second,
+		time.Duration(authSettings.RevocationRefresh)*time.Second, logger)
+	if authSettings.AdminName != "" {
+		if err := us.Bootstrap(authSettings.AdminName, authSettings.AdminPassword); err != nil {
+			logger.Fatal("can't bootstrap admin %s: %v", authSettings.AdminName, err.Cause().Error())
+		}
+	}
 
 	quit := make(chan struct{})
-	transmitter := service.NewTransmitService(bs, cacheStorage, logger, quit)
+	transmitter := service.NewTransmitService(bs, cacheStorage, cacheKeys,
+		time.Duration(cacheSettings.RefreshInterval)*time.Second, time.Duration(cacheSettings.RefreshJitter)*time.Second, logger, quit)
 	go transmitter.Start()
 
+	handler := rest.NewHandler(serverSettings, logger, bs, fs, ts, us, jobs, transmitter)
+
 	if err := handler.Run(); err != nil {
 		logger.Error("can't run http server: %v", err.Error())
 	}
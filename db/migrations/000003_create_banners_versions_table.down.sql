@@ -0,0 +1 @@
+DROP TABLE banners_versions;
\ No newline at end of file
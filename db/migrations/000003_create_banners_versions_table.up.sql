@@ -0,0 +1,12 @@
+CREATE TABLE banners_versions (
+    id SERIAL PRIMARY KEY,
+    banner_id INTEGER REFERENCES banners(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    version INTEGER NOT NULL,
+    feature_id INTEGER,
+    tag_ids INTEGER[] NOT NULL DEFAULT '{}',
+    content JSONB,
+    is_active BOOLEAN,
+    author VARCHAR(50),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (banner_id, version)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE banners_versions DROP COLUMN active_from, DROP COLUMN active_until;
+ALTER TABLE banners DROP CONSTRAINT banners_activation_window_check, DROP COLUMN active_from, DROP COLUMN active_until;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE banners
+    ADD COLUMN active_from TIMESTAMPTZ,
+    ADD COLUMN active_until TIMESTAMPTZ,
+    ADD CONSTRAINT banners_activation_window_check CHECK (active_from IS NULL OR active_until IS NULL OR active_from < active_until);
+
+ALTER TABLE banners_versions
+    ADD COLUMN active_from TIMESTAMPTZ,
+    ADD COLUMN active_until TIMESTAMPTZ;
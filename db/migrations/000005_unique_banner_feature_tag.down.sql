@@ -0,0 +1,2 @@
+ALTER TABLE banners_tags DROP CONSTRAINT banners_tags_feature_id_tag_id_key, DROP CONSTRAINT banners_tags_banner_id_feature_id_fkey, DROP COLUMN feature_id;
+ALTER TABLE banners DROP CONSTRAINT banners_id_feature_id_key;
\ No newline at end of file
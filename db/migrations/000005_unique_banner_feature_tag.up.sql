@@ -0,0 +1,9 @@
+ALTER TABLE banners ADD CONSTRAINT banners_id_feature_id_key UNIQUE (id, feature_id);
+
+ALTER TABLE banners_tags ADD COLUMN feature_id INTEGER;
+UPDATE banners_tags bt SET feature_id = b.feature_id FROM banners b WHERE b.id = bt.banner_id;
+
+-- fails if some feature and tag pair is already shared by several banners, resolve them by hand first
+ALTER TABLE banners_tags
+    ADD CONSTRAINT banners_tags_banner_id_feature_id_fkey FOREIGN KEY (banner_id, feature_id) REFERENCES banners(id, feature_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD CONSTRAINT banners_tags_feature_id_tag_id_key UNIQUE (feature_id, tag_id);
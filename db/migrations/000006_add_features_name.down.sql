@@ -0,0 +1 @@
+ALTER TABLE features DROP COLUMN name;
\ No newline at end of file
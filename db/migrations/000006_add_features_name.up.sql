@@ -0,0 +1,3 @@
+ALTER TABLE features ADD COLUMN name VARCHAR(50);
+UPDATE features SET name = 'feature_' || id WHERE name IS NULL;
+ALTER TABLE features ALTER COLUMN name SET NOT NULL, ADD CONSTRAINT features_name_key UNIQUE (name);
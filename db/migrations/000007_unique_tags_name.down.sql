@@ -0,0 +1 @@
+ALTER TABLE tags DROP CONSTRAINT tags_name_key, ALTER COLUMN name DROP NOT NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+UPDATE tags SET name = 'tag_' || id WHERE name IS NULL;
+ALTER TABLE tags ALTER COLUMN name SET NOT NULL, ADD CONSTRAINT tags_name_key UNIQUE (name);
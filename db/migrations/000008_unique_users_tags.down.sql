@@ -0,0 +1 @@
+ALTER TABLE users_tags DROP CONSTRAINT users_tags_user_id_tag_id_key;
\ No newline at end of file
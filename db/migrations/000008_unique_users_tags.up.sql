@@ -0,0 +1,2 @@
+DELETE FROM users_tags a USING users_tags b WHERE a.id > b.id AND a.user_id = b.user_id AND a.tag_id = b.tag_id;
+ALTER TABLE users_tags ADD CONSTRAINT users_tags_user_id_tag_id_key UNIQUE (user_id, tag_id);
@@ -0,0 +1 @@
+ALTER TABLE features DROP COLUMN content_schema;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE features ADD COLUMN content_schema JSONB;
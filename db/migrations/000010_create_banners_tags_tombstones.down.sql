@@ -0,0 +1 @@
+DROP TRIGGER banners_tags_tombstone ON banners_tags; DROP FUNCTION banners_tags_tombstone(); DROP INDEX banners_updated_at_idx; DROP TABLE banners_tags_tombstones;
\ No newline at end of file
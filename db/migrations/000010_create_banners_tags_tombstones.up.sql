@@ -0,0 +1,22 @@
+CREATE TABLE banners_tags_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+    feature_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX banners_tags_tombstones_deleted_at_idx ON banners_tags_tombstones (deleted_at);
+CREATE INDEX banners_updated_at_idx ON banners (updated_at);
+
+-- every (feature, tag) pair that stops pointing to a banner is recorded, so the cache can drop its key
+CREATE FUNCTION banners_tags_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' OR OLD.feature_id IS DISTINCT FROM NEW.feature_id OR OLD.tag_id IS DISTINCT FROM NEW.tag_id THEN
+        INSERT INTO banners_tags_tombstones (feature_id, tag_id) VALUES (OLD.feature_id, OLD.tag_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER banners_tags_tombstone AFTER UPDATE OR DELETE ON banners_tags
+    FOR EACH ROW EXECUTE FUNCTION banners_tags_tombstone();
\ No newline at end of file
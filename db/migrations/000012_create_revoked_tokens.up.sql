@@ -0,0 +1,10 @@
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+-- tokens of the user issued before this moment are rejected
+ALTER TABLE users ADD COLUMN tokens_revoked_at TIMESTAMPTZ;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- users without a password can't sign in, the first admin is set up on start from AUTH_ADMIN_NAME and AUTH_ADMIN_PASSWORD
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(100);
\ No newline at end of file
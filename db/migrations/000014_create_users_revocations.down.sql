@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN tokens_revoked_at TIMESTAMPTZ; UPDATE users u SET tokens_revoked_at = r.revoked_at FROM users_revocations r WHERE r.name = u.name; DROP TABLE users_revocations;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE users_revocations (
+    name VARCHAR(50) PRIMARY KEY,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- kept by name, so tokens of a deleted user stay rejected
+INSERT INTO users_revocations (name, revoked_at) SELECT name, tokens_revoked_at FROM users WHERE tokens_revoked_at IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN tokens_revoked_at;
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM banners_versions v WHERE NOT EXISTS (SELECT 1 FROM banners b WHERE b.id = v.banner_id); ALTER TABLE banners_versions ADD CONSTRAINT banners_versions_banner_id_fkey FOREIGN KEY (banner_id) REFERENCES banners(id) ON DELETE CASCADE ON UPDATE CASCADE;
\ No newline at end of file
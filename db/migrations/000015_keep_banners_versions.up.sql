@@ -0,0 +1,2 @@
+-- versions outlive their banner, so a deleted banner can be restored from its history
+ALTER TABLE banners_versions DROP CONSTRAINT banners_versions_banner_id_fkey;
\ No newline at end of file
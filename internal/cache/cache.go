@@ -1,8 +1,26 @@
 package cache
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("key not found in cache")
+
 type Storager[T any] interface {
-	Set(key string, value T) error
-	Get(key string) (T, error)
-	Delete(key string) error
+	Set(ctx context.Context, key string, value T) error
+	Get(ctx context.Context, key string) (T, error)
+	Delete(ctx context.Context, key string) error
+	// MGet returns values of the keys found, missing keys are left out of the map
+	MGet(ctx context.Context, keys []string) (map[string]T, error)
+	MSet(ctx context.Context, values map[string]T) error
+	DeleteMany(ctx context.Context, keys []string) error
 	Close() error
 }
+
+// TTLer is implemented by storages able to tell how long an entry has left to live.
+// A zero duration means the entry never expires.
+type TTLer interface {
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pointerTTL is how long the current generation is trusted before it is read again,
+// readers keep seeing the previous snapshot for at most that long after a rebuild.
+const pointerTTL = time.Second
+
+// Rebuilder is implemented by storages that can replace all their entries at once.
+type Rebuilder[T any] interface {
+	Rebuild(ctx context.Context, values map[string]T) error
+}
+
+// Generations keeps every full rebuild in its own key namespace and switches readers to it
+// by flipping a single pointer key once the rebuild is written. Entries of previous generations
+// are never read again and go away when they expire.
+type Generations[T any] struct {
+	storage Storager[T]
+	pointer Storager[int64]
+	keys    KeyBuilder
+
+	mu        sync.Mutex
+	current   int64
+	checkedAt time.Time
+	building  int64
+}
+
+// NewGenerations wraps storage, the pointer storage must keep its entries without expiration.
+func NewGenerations[T any](storage Storager[T], pointer Storager[int64], keys KeyBuilder) *Generations[T] {
+	return &Generations[T]{
+		storage: storage,
+		pointer: pointer,
+		keys:    keys,
+	}
+}
+
+// generations returns the generation to read from and every generation writes must go to.
+func (g *Generations[T]) generations(ctx context.Context) (int64, []int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.checkedAt) > pointerTTL {
+		current, err := g.pointer.Get(ctx, g.keys.Pointer())
+		switch {
+		case err == nil:
+			g.current = current
+		case !errors.Is(err, ErrKeyNotFound):
+			return 0, nil, fmt.Errorf("can't get cache generation: %w", err)
+		}
+		g.checkedAt = time.Now()
+	}
+	writes := []int64{g.current}
+	if g.building != 0 && g.building != g.current {
+		// a change made during a rebuild must not be lost when the rebuild is switched on
+		writes = append(writes, g.building)
+	}
+	return g.current, writes, nil
+}
+
+func (g *Generations[T]) Set(ctx context.Context, key string, value T) error {
+	return g.MSet(ctx, map[string]T{key: value})
+}
+
+func (g *Generations[T]) Get(ctx context.Context, key string) (T, error) {
+	current, _, err := g.generations(ctx)
+	if err != nil {
+		return *new(T), err
+	}
+	return g.storage.Get(ctx, g.keys.Generation(current, key))
+}
+
+func (g *Generations[T]) Delete(ctx context.Context, key string) error {
+	return g.DeleteMany(ctx, []string{key})
+}
+
+func (g *Generations[T]) MGet(ctx context.Context, keys []string) (map[string]T, error) {
+	current, _, err := g.generations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = g.keys.Generation(current, key)
+	}
+	found, err := g.storage.MGet(ctx, prefixed)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]T, len(found))
+	for i, key := range keys {
+		if value, ok := found[prefixed[i]]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (g *Generations[T]) MSet(ctx context.Context, values map[string]T) error {
+	_, writes, err := g.generations(ctx)
+	if err != nil {
+		return err
+	}
+	prefixed := make(map[string]T, len(values)*len(writes))
+	for _, generation := range writes {
+		for key, value := range values {
+			prefixed[g.keys.Generation(generation, key)] = value
+		}
+	}
+	return g.storage.MSet(ctx, prefixed)
+}
+
+func (g *Generations[T]) DeleteMany(ctx context.Context, keys []string) error {
+	_, writes, err := g.generations(ctx)
+	if err != nil {
+		return err
+	}
+	prefixed := make([]string, 0, len(keys)*len(writes))
+	for _, generation := range writes {
+		for _, key := range keys {
+			prefixed = append(prefixed, g.keys.Generation(generation, key))
+		}
+	}
+	return g.storage.DeleteMany(ctx, prefixed)
+}
+
+// Rebuild writes values into a new generation and switches readers to it, keys missing
+// from values are gone from the new snapshot. Writes made while it runs go to both generations,
+// but only in this process and only after it started: callers replay what changed since they
+// read values once it returns.
+func (g *Generations[T]) Rebuild(ctx context.Context, values map[string]T) error {
+	generation := time.Now().UnixNano()
+	g.mu.Lock()
+	g.building = generation
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.building = 0
+		g.mu.Unlock()
+	}()
+
+	prefixed := make(map[string]T, len(values))
+	for key, value := range values {
+		prefixed[g.keys.Generation(generation, key)] = value
+	}
+	if err := g.storage.MSet(ctx, prefixed); err != nil {
+		return fmt.Errorf("can't write cache generation %d: %w", generation, err)
+	}
+	if err := g.pointer.Set(ctx, g.keys.Pointer(), generation); err != nil {
+		return fmt.Errorf("can't switch to cache generation %d: %w", generation, err)
+	}
+
+	g.mu.Lock()
+	g.current, g.checkedAt = generation, time.Now()
+	g.mu.Unlock()
+	return nil
+}
+
+func (g *Generations[T]) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttler, ok := g.storage.(TTLer)
+	if !ok {
+		return 0, errors.ErrUnsupported
+	}
+	current, _, err := g.generations(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return ttler.TTL(ctx, g.keys.Generation(current, key))
+}
+
+func (g *Generations[T]) Close() error {
+	return errors.Join(g.storage.Close(), g.pointer.Close())
+}
+
+var _ Storager[map[string]any] = &Generations[map[string]any]{}
+var _ Rebuilder[map[string]any] = &Generations[map[string]any]{}
+var _ TTLer = &Generations[map[string]any]{}
@@ -0,0 +1,96 @@
+package cache_test
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/antsrp/banner_service/internal/cache"
+	"github.com/antsrp/banner_service/internal/cache/memory"
+	ds "github.com/antsrp/banner_service/pkg/infrastructure/cache"
+	"github.com/antsrp/banner_service/pkg/logger/slog"
+)
+
+// switchingPointer calls beforeSwitch right before a rebuild switches readers to the new generation.
+type switchingPointer struct {
+	cache.Storager[int64]
+	beforeSwitch func()
+}
+
+func (p *switchingPointer) Set(ctx context.Context, key string, value int64) error {
+	if p.beforeSwitch != nil {
+		p.beforeSwitch()
+	}
+	return p.Storager.Set(ctx, key, value)
+}
+
+func TestGenerationsRebuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		first      map[string]int
+		second     map[string]int
+		wantBefore map[string]int
+		wantAfter  map[string]int
+	}{
+		{
+			name:       "catalogue of the cache size",
+			size:       4,
+			first:      map[string]int{"a": 1, "b": 1, "c": 1, "d": 1},
+			second:     map[string]int{"a": 2, "b": 2, "c": 2, "d": 2},
+			wantBefore: map[string]int{"a": 1, "b": 1, "c": 1, "d": 1},
+			wantAfter:  map[string]int{"a": 2, "b": 2, "c": 2, "d": 2},
+		},
+		{
+			name:       "keys missing from the new snapshot are gone",
+			size:       4,
+			first:      map[string]int{"a": 1, "b": 1},
+			second:     map[string]int{"a": 2},
+			wantBefore: map[string]int{"a": 1, "b": 1},
+			wantAfter:  map[string]int{"a": 2},
+		},
+		{
+			name:       "new keys appear with the switch",
+			size:       4,
+			first:      map[string]int{"a": 1},
+			second:     map[string]int{"a": 2, "b": 2},
+			wantBefore: map[string]int{"a": 1},
+			wantAfter:  map[string]int{"a": 2, "b": 2},
+		},
+	}
+	keys := []string{"a", "b", "c", "d"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			logger := slog.NewTextLogger(io.Discard)
+			pointer := &switchingPointer{Storager: memory.New[int64](1, 0, logger)}
+			g := cache.NewGenerations[int](memory.NewStorage[int](ds.Settings{Size: tt.size}, logger), pointer, cache.NewKeyBuilder("test", 1))
+
+			if err := g.Rebuild(ctx, tt.first); err != nil {
+				t.Fatalf("Rebuild() error = %v", err)
+			}
+			pointer.beforeSwitch = func() {
+				// the new generation is written, readers must still get the whole previous one
+				got, err := g.MGet(ctx, keys)
+				if err != nil {
+					t.Fatalf("MGet() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.wantBefore) {
+					t.Errorf("MGet() before the switch = %v, want %v", got, tt.wantBefore)
+				}
+			}
+			if err := g.Rebuild(ctx, tt.second); err != nil {
+				t.Fatalf("Rebuild() error = %v", err)
+			}
+
+			got, err := g.MGet(ctx, keys)
+			if err != nil {
+				t.Fatalf("MGet() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantAfter) {
+				t.Errorf("MGet() after the switch = %v, want %v", got, tt.wantAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultKeyPrefix  = "banner_service"
+	DefaultKeyVersion = 1
+)
+
+// KeyBuilder builds every key the service puts into the cache. All keys share the prefix,
+// so environments sharing one redis database don't collide, and the schema version,
+// so bumping it after a change of cached values makes old entries unreachable.
+type KeyBuilder struct {
+	prefix string
+}
+
+func NewKeyBuilder(prefix string, version int) KeyBuilder {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	if version <= 0 {
+		version = DefaultKeyVersion
+	}
+	return KeyBuilder{prefix: fmt.Sprintf("%s:v%d:", prefix, version)}
+}
+
+// Banner is the key of the banner shown for the feature and the tag.
+func (b KeyBuilder) Banner(featureID, tagID int) string {
+	return fmt.Sprintf("%sbanner:%d:%d", b.prefix, featureID, tagID)
+}
+
+// Pointer is the key holding the current cache generation.
+func (b KeyBuilder) Pointer() string {
+	return b.prefix + "generation"
+}
+
+// Generation moves a key built by b into the namespace of the generation.
+func (b KeyBuilder) Generation(generation int64, key string) string {
+	return fmt.Sprintf("%sgen:%d:%s", b.prefix, generation, strings.TrimPrefix(key, b.prefix))
+}
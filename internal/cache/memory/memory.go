@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/cache"
+	ds "github.com/antsrp/banner_service/pkg/infrastructure/cache"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+// defaultSize bounds the cache when CACHE_SIZE is not set.
+const defaultSize = 10000
+
+type entry[T any] struct {
+	key       string
+	value     T
+	expiresAt time.Time
+}
+
+// Storage is an in-process cache that evicts the least recently used entry when it is full.
+type Storage[T any] struct {
+	mu         sync.Mutex
+	size       int
+	expiration time.Duration
+	items      map[string]*list.Element
+	order      *list.List // front is the most recently used
+	logger     logger.Logger
+}
+
+// NewStorage creates the cache to be wrapped by cache.Generations. A rebuild writes the new generation
+// while readers still use the previous one, so settings.Size is kept for each of the two.
+func NewStorage[T any](settings ds.Settings, l logger.Logger) *Storage[T] {
+	size := settings.Size
+	if size <= 0 {
+		size = defaultSize
+	}
+	return New[T](2*size, time.Duration(settings.ExpirationTime)*time.Minute, l)
+}
+
+// New creates a cache holding at most size entries, each for the expiration time; zero expiration keeps entries until evicted.
+func New[T any](size int, expiration time.Duration, l logger.Logger) *Storage[T] {
+	if size <= 0 {
+		size = defaultSize
+	}
+	l.Info("in-memory cache created, size %d", size)
+	return &Storage[T]{
+		size:       size,
+		expiration: expiration,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		logger:     l,
+	}
+}
+
+func (s *Storage[T]) Set(_ context.Context, key string, value T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(key, value, s.expiresAt())
+	return nil
+}
+
+func (s *Storage[T]) Get(_ context.Context, key string) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.get(key)
+	if !ok {
+		return *new(T), cache.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (s *Storage[T]) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+func (s *Storage[T]) MGet(_ context.Context, keys []string) (map[string]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make(map[string]T, len(keys))
+	for _, key := range keys {
+		if value, ok := s.get(key); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (s *Storage[T]) MSet(_ context.Context, values map[string]T) error {
+	expiresAt := s.expiresAt()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range values {
+		s.set(key, value, expiresAt)
+	}
+	return nil
+}
+
+func (s *Storage[T]) DeleteMany(_ context.Context, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := s.items[key]; ok {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+func (s *Storage[T]) TTL(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.get(key); !ok {
+		return 0, cache.ErrKeyNotFound
+	}
+	expiresAt := s.items[key].Value.(*entry[T]).expiresAt
+	if expiresAt.IsZero() {
+		return 0, nil
+	}
+	return time.Until(expiresAt), nil
+}
+
+func (s *Storage[T]) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = make(map[string]*list.Element)
+	s.order.Init()
+	s.logger.Info("in-memory cache closed")
+	return nil
+}
+
+func (s *Storage[T]) expiresAt() time.Time {
+	if s.expiration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(s.expiration)
+}
+
+// set and get expect the lock to be held.
+func (s *Storage[T]) set(key string, value T, expiresAt time.Time) {
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*entry[T])
+		e.value, e.expiresAt = value, expiresAt
+		s.order.MoveToFront(el)
+		return
+	}
+	s.items[key] = s.order.PushFront(&entry[T]{key: key, value: value, expiresAt: expiresAt})
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+}
+
+func (s *Storage[T]) get(key string) (T, bool) {
+	el, ok := s.items[key]
+	if !ok {
+		return *new(T), false
+	}
+	e := el.Value.(*entry[T])
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		s.remove(el)
+		return *new(T), false
+	}
+	s.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (s *Storage[T]) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*entry[T]).key)
+}
+
+var _ cache.Storager[map[string]any] = &Storage[map[string]any]{}
+var _ cache.TTLer = &Storage[map[string]any]{}
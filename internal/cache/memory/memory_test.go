@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/cache"
+	"github.com/antsrp/banner_service/pkg/logger/slog"
+)
+
+func newTestStorage(size int, expiration time.Duration) *Storage[int] {
+	return New[int](size, expiration, slog.NewTextLogger(io.Discard))
+}
+
+func TestStorageEviction(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		// ops are applied in order: "set:<key>" writes the key, "get:<key>" reads it
+		ops  []string
+		want map[string]int
+	}{
+		{
+			name: "fits into size",
+			size: 3,
+			ops:  []string{"set:a", "set:b", "set:c"},
+			want: map[string]int{"a": 1, "b": 1, "c": 1},
+		},
+		{
+			name: "least recently set is evicted",
+			size: 2,
+			ops:  []string{"set:a", "set:b", "set:c"},
+			want: map[string]int{"b": 1, "c": 1},
+		},
+		{
+			name: "read moves the key to the front",
+			size: 2,
+			ops:  []string{"set:a", "set:b", "get:a", "set:c"},
+			want: map[string]int{"a": 1, "c": 1},
+		},
+		{
+			name: "rewrite moves the key to the front",
+			size: 2,
+			ops:  []string{"set:a", "set:b", "set:a", "set:c"},
+			want: map[string]int{"a": 2, "c": 1},
+		},
+		{
+			name: "zero size falls back to the default",
+			size: 0,
+			ops:  []string{"set:a", "set:b", "set:c"},
+			want: map[string]int{"a": 1, "b": 1, "c": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(tt.size, 0)
+			writes := make(map[string]int)
+			for _, op := range tt.ops {
+				key := op[4:]
+				switch op[:4] {
+				case "set:":
+					writes[key]++
+					if err := s.Set(ctx, key, writes[key]); err != nil {
+						t.Fatalf("Set(%q) error = %v", key, err)
+					}
+				case "get:":
+					if _, err := s.Get(ctx, key); err != nil {
+						t.Fatalf("Get(%q) error = %v", key, err)
+					}
+				}
+			}
+			got, err := s.MGet(ctx, []string{"a", "b", "c"})
+			if err != nil {
+				t.Fatalf("MGet() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MGet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorageExpiration(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiration time.Duration
+		wait       time.Duration
+		wantErr    error
+		wantTTL    bool
+	}{
+		{name: "no expiration", expiration: 0, wait: 10 * time.Millisecond},
+		{name: "not expired yet", expiration: time.Hour, wait: 0, wantTTL: true},
+		{name: "expired", expiration: 5 * time.Millisecond, wait: 20 * time.Millisecond, wantErr: cache.ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(10, tt.expiration)
+			if err := s.Set(ctx, "a", 1); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			time.Sleep(tt.wait)
+
+			ttl, err := s.TTL(ctx, "a")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TTL() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantTTL != (ttl > 0) {
+				t.Errorf("TTL() = %v, want positive %v", ttl, tt.wantTTL)
+			}
+			if _, err := s.Get(ctx, "a"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStorageDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete []string
+		want   map[string]int
+	}{
+		{name: "nothing", delete: nil, want: map[string]int{"a": 1, "b": 2, "c": 3}},
+		{name: "some keys", delete: []string{"a", "c"}, want: map[string]int{"b": 2}},
+		{name: "missing keys are ignored", delete: []string{"x", "b"}, want: map[string]int{"a": 1, "c": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(10, 0)
+			if err := s.MSet(ctx, map[string]int{"a": 1, "b": 2, "c": 3}); err != nil {
+				t.Fatalf("MSet() error = %v", err)
+			}
+			if err := s.DeleteMany(ctx, tt.delete); err != nil {
+				t.Fatalf("DeleteMany() error = %v", err)
+			}
+			got, err := s.MGet(ctx, []string{"a", "b", "c", "x"})
+			if err != nil {
+				t.Fatalf("MGet() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MGet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,11 +13,13 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// pipelineSize bounds the number of commands sent in one round trip.
+const pipelineSize = 1000
+
 type Storage[T any] struct {
 	client     *redis.Client
 	logger     logger.Logger
 	expiration time.Duration
-	ctx        context.Context
 }
 
 func NewStorage[T any](settings ds.Settings, l logger.Logger) (*Storage[T], error) {
@@ -26,9 +29,7 @@ func NewStorage[T any](settings ds.Settings, l logger.Logger) (*Storage[T], erro
 		DB:       settings.DBName,
 	})
 
-	ctx := context.Background()
-
-	if _, err := client.Ping(ctx).Result(); err != nil {
+	if _, err := client.Ping(context.Background()).Result(); err != nil {
 		return nil, err
 	}
 	l.Info("redis connection opened")
@@ -37,32 +38,138 @@ func NewStorage[T any](settings ds.Settings, l logger.Logger) (*Storage[T], erro
 		client:     client,
 		logger:     l,
 		expiration: time.Duration(settings.ExpirationTime) * time.Minute,
-		ctx:        ctx,
 	}, nil
 }
 
-func (s Storage[T]) Set(key string, value T) error {
+func (s Storage[T]) Set(ctx context.Context, key string, value T) error {
 	data, err := mapper.ToJSON[T](value, &mapper.DefaultIndent)
 	if err != nil {
 		return fmt.Errorf("can't put data in cache: %w", err)
 	}
-	return s.client.Set(s.ctx, key, data, s.expiration).Err()
+	return s.client.Set(ctx, key, data, s.expiration).Err()
 }
 
-func (s Storage[T]) Get(key string) (T, error) {
-	status := s.client.Get(s.ctx, key)
-	if err := status.Err(); err != nil {
+func (s Storage[T]) Get(ctx context.Context, key string) (T, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			err = cache.ErrKeyNotFound
+		}
 		return *new(T), err
 	}
-	var returnVal T
-	if err := status.Scan(&returnVal); err != nil {
-		return *new(T), err
+	value, err := mapper.FromJSON[T](data)
+	if err != nil {
+		return *new(T), fmt.Errorf("can't get data from cache: %w", err)
+	}
+	return *value, nil
+}
+
+func (s Storage[T]) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+func (s Storage[T]) MGet(ctx context.Context, keys []string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	for start := 0; start < len(keys); start += pipelineSize {
+		chunk := keys[start:min(start+pipelineSize, len(keys))]
+		cmds := make([]*redis.StringCmd, len(chunk))
+		if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range chunk {
+				cmds[i] = pipe.Get(ctx, key)
+			}
+			return nil
+		}); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		for i, cmd := range cmds {
+			data, err := cmd.Bytes()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			value, err := mapper.FromJSON[T](data)
+			if err != nil {
+				return nil, fmt.Errorf("can't get data from cache: %w", err)
+			}
+			values[chunk[i]] = *value
+		}
 	}
-	return returnVal, nil
+	return values, nil
 }
 
-func (s Storage[T]) Delete(key string) error {
-	return s.client.Del(s.ctx, key).Err()
+func (s Storage[T]) MSet(ctx context.Context, values map[string]T) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	for start := 0; start < len(keys); start += pipelineSize {
+		chunk := keys[start:min(start+pipelineSize, len(keys))]
+		if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range chunk {
+				data, err := mapper.ToJSON[T](values[key], &mapper.DefaultIndent)
+				if err != nil {
+					return fmt.Errorf("can't put data in cache: %w", err)
+				}
+				pipe.Set(ctx, key, data, s.expiration)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s Storage[T]) DeleteMany(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += pipelineSize {
+		chunk := keys[start:min(start+pipelineSize, len(keys))]
+		if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range chunk {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s Storage[T]) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch ttl {
+	case -2:
+		return 0, cache.ErrKeyNotFound
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s Storage[T]) Publish(ctx context.Context, channel, message string) error {
+	return s.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe calls fn for every message published to the channel until the returned function is called.
+// Messages published while the connection is being restored are lost.
+func (s Storage[T]) Subscribe(channel string, fn func(message string)) (func() error, error) {
+	ctx := context.Background()
+	pubsub := s.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("can't subscribe to channel %s: %w", channel, err)
+	}
+	go func() {
+		for msg := range pubsub.Channel() {
+			fn(msg.Payload)
+		}
+	}()
+	return pubsub.Close, nil
 }
 
 func (s Storage[T]) Close() error {
@@ -77,3 +184,4 @@ func (s Storage[T]) Close() error {
 }
 
 var _ cache.Storager[map[string]any] = Storage[map[string]any]{}
+var _ cache.TTLer = Storage[map[string]any]{}
@@ -0,0 +1,173 @@
+package tiered
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/cache"
+	"github.com/antsrp/banner_service/internal/cache/memory"
+	"github.com/antsrp/banner_service/internal/cache/redis"
+	ds "github.com/antsrp/banner_service/pkg/infrastructure/cache"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+const (
+	defaultLocalExpiration = 10 * time.Second
+	defaultChannel         = "banner_service:invalidate"
+)
+
+// Storage keeps a short-lived copy of redis entries in process memory.
+// Every change is announced over redis pub/sub, so other instances drop their local copies.
+type Storage[T any] struct {
+	local       *memory.Storage[T]
+	remote      *redis.Storage[T]
+	channel     string
+	instance    string
+	unsubscribe func() error
+	logger      logger.Logger
+}
+
+func NewStorage[T any](settings ds.Settings, l logger.Logger) (*Storage[T], error) {
+	remote, err := redis.NewStorage[T](settings, l)
+	if err != nil {
+		return nil, err
+	}
+	expiration := time.Duration(settings.LocalExpirationTime) * time.Second
+	if expiration <= 0 {
+		expiration = defaultLocalExpiration
+	}
+	channel := settings.Channel
+	if channel == "" {
+		channel = defaultChannel
+	}
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		remote.Close()
+		return nil, fmt.Errorf("can't generate cache instance id: %w", err)
+	}
+
+	s := &Storage[T]{
+		local:    memory.New[T](settings.Size, expiration, l),
+		remote:   remote,
+		channel:  channel,
+		instance: hex.EncodeToString(buf),
+		logger:   l,
+	}
+	s.unsubscribe, err = remote.Subscribe(channel, s.invalidated)
+	if err != nil {
+		remote.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// invalidated handles a message "<instance> <key>\n<key>..." sent by some instance.
+func (s *Storage[T]) invalidated(message string) {
+	instance, keys, ok := strings.Cut(message, " ")
+	if !ok || instance == s.instance {
+		return
+	}
+	s.local.DeleteMany(context.Background(), strings.Split(keys, "\n"))
+}
+
+func (s *Storage[T]) publish(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := s.remote.Publish(ctx, s.channel, s.instance+" "+strings.Join(keys, "\n")); err != nil {
+		s.logger.Error("can't publish invalidation of %d keys: %v", len(keys), err.Error())
+	}
+}
+
+func (s *Storage[T]) Set(ctx context.Context, key string, value T) error {
+	if err := s.remote.Set(ctx, key, value); err != nil {
+		s.local.Delete(ctx, key)
+		return err
+	}
+	s.local.Set(ctx, key, value)
+	s.publish(ctx, key)
+	return nil
+}
+
+func (s *Storage[T]) Get(ctx context.Context, key string) (T, error) {
+	if value, err := s.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+	value, err := s.remote.Get(ctx, key)
+	if err != nil {
+		return *new(T), err
+	}
+	s.local.Set(ctx, key, value)
+	return value, nil
+}
+
+func (s *Storage[T]) Delete(ctx context.Context, key string) error {
+	s.local.Delete(ctx, key)
+	err := s.remote.Delete(ctx, key)
+	// other instances must drop the key even if redis failed to
+	s.publish(ctx, key)
+	return err
+}
+
+func (s *Storage[T]) MGet(ctx context.Context, keys []string) (map[string]T, error) {
+	values, _ := s.local.MGet(ctx, keys)
+	missing := make([]string, 0, len(keys)-len(values))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+	found, err := s.remote.MGet(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	s.local.MSet(ctx, found)
+	for key, value := range found {
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (s *Storage[T]) MSet(ctx context.Context, values map[string]T) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	if err := s.remote.MSet(ctx, values); err != nil {
+		s.local.DeleteMany(ctx, keys)
+		return err
+	}
+	s.local.MSet(ctx, values)
+	s.publish(ctx, keys...)
+	return nil
+}
+
+func (s *Storage[T]) DeleteMany(ctx context.Context, keys []string) error {
+	s.local.DeleteMany(ctx, keys)
+	err := s.remote.DeleteMany(ctx, keys)
+	s.publish(ctx, keys...)
+	return err
+}
+
+// TTL reports the lifetime left in redis, local copies live shorter anyway.
+func (s *Storage[T]) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.remote.TTL(ctx, key)
+}
+
+func (s *Storage[T]) Close() error {
+	if err := s.unsubscribe(); err != nil {
+		s.logger.Error("can't unsubscribe from channel %s: %v", s.channel, err.Error())
+	}
+	s.local.Close()
+	return s.remote.Close()
+}
+
+var _ cache.Storager[map[string]any] = &Storage[map[string]any]{}
+var _ cache.TTLer = &Storage[map[string]any]{}
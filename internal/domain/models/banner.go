@@ -4,12 +4,22 @@ import "time"
 
 type BannerContent map[string]any
 
+type BannerStatus string
+
+const (
+	BannerStatusScheduled BannerStatus = "scheduled"
+	BannerStatusLive      BannerStatus = "live"
+	BannerStatusExpired   BannerStatus = "expired"
+)
+
 type BannerCommon struct {
-	ID        int           `json:"id,omitempty"`
-	FeatureID int           `json:"feature_id,omitempty"`
-	TagIDS    []int         `json:"tag_ids,omitempty"`
-	Content   BannerContent `json:"content,omitempty"`
-	IsActive  *bool         `json:"is_active,omitempty"`
+	ID          int           `json:"id,omitempty"`
+	FeatureID   int           `json:"feature_id,omitempty"`
+	TagIDS      []int         `json:"tag_ids,omitempty"`
+	Content     BannerContent `json:"content,omitempty"`
+	IsActive    *bool         `json:"is_active,omitempty"`
+	ActiveFrom  *time.Time    `json:"active_from,omitempty"`
+	ActiveUntil *time.Time    `json:"active_until,omitempty"`
 }
 
 type Banner struct {
@@ -17,3 +27,36 @@ type Banner struct {
 	CreatedAt time.Time `json:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
 }
+
+// Status reports where the moment t falls relative to the banner's activation window.
+func (b BannerCommon) Status(t time.Time) BannerStatus {
+	if b.ActiveFrom != nil && t.Before(*b.ActiveFrom) {
+		return BannerStatusScheduled
+	}
+	if b.ActiveUntil != nil && !t.Before(*b.ActiveUntil) {
+		return BannerStatusExpired
+	}
+	return BannerStatusLive
+}
+
+// BannerConflict describes a (feature, tag) pair already owned by another banner.
+type BannerConflict struct {
+	BannerID  int `json:"banner_id"`
+	FeatureID int `json:"feature_id"`
+	TagID     int `json:"tag_id"`
+}
+
+// BannerKey is a (feature, tag) pair a banner is shown for.
+type BannerKey struct {
+	FeatureID int `json:"feature_id"`
+	TagID     int `json:"tag_id"`
+}
+
+// BannerChanges lists what happened to banners in a period ending at Until.
+type BannerChanges struct {
+	// Changed holds banners that were created, modified or entered or left their activation window
+	Changed []Banner
+	// Removed holds pairs that stopped pointing to a banner
+	Removed []BannerKey
+	Until   time.Time
+}
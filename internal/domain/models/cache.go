@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type CacheRefresh struct {
+	StartedAt   time.Time `json:"started_at"`
+	DurationMS  int64     `json:"duration_ms"`
+	Full        bool      `json:"full"`
+	KeysWritten int       `json:"keys_written"`
+	KeysDeleted int       `json:"keys_deleted"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type CacheStatus struct {
+	// LastRefresh is the last refresh attempt, successful or not
+	LastRefresh *CacheRefresh `json:"last_refresh"`
+	// LastSuccessAt is when the last successful refresh started
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	Interval      string     `json:"interval"`
+	Jitter        string     `json:"jitter"`
+}
+
+// CachedBanner puts the cache entry for a (feature, tag) pair next to the banner the database serves for it.
+type CachedBanner struct {
+	Key    string  `json:"key"`
+	Cached *Banner `json:"cached"`
+	// TTLSeconds is nil when the storage can't tell it and 0 when the entry never expires
+	TTLSeconds *int64  `json:"ttl_seconds"`
+	Database   *Banner `json:"database"`
+	Differ     bool    `json:"differ"`
+}
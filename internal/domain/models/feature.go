@@ -0,0 +1,11 @@
+package models
+
+import "encoding/json"
+
+type Feature struct {
+	ID            int             `json:"id"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	ContentSchema json.RawMessage `json:"content_schema,omitempty"`
+	BannersCount  int             `json:"banners_count"`
+}
@@ -0,0 +1,25 @@
+package models
+
+type ImportMode string
+
+const (
+	ImportModeUpsert       ImportMode = "upsert"
+	ImportModeSkipExisting ImportMode = "skip_existing"
+)
+
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	Mode    ImportMode        `json:"mode"`
+	DryRun  bool              `json:"dry_run"`
+	Applied bool              `json:"applied"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Errors  []ImportLineError `json:"errors"`
+	// Affected holds the pairs written banners had before and after the import, so their cache can be dropped
+	Affected []BannerKey `json:"-"`
+}
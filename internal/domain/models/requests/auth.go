@@ -1,7 +1,18 @@
 package requests
 
 type SignInRequest struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SignOutRequest struct {
+	Token string `json:"-"`
+	// RefreshToken is optional, when set its family is revoked as well
+	RefreshToken string `json:"refresh_token"`
 }
 
 type SignInResponse struct {
@@ -18,8 +18,9 @@ type UserBannerResponse struct {
 
 type GetBannersRequest struct {
 	UserBannerRequest
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
+	Status models.BannerStatus `json:"status"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
 }
 
 type GetBannersResponse struct {
@@ -38,6 +39,9 @@ type CreateBannerResponse struct {
 
 type UpdateBannerRequest struct {
 	models.BannerCommon
+	// ClearActiveFrom and ClearActiveUntil are set when the bound is passed as null and has to be removed
+	ClearActiveFrom  bool `json:"-"`
+	ClearActiveUntil bool `json:"-"`
 }
 
 type UpdateBannerResponse struct {
@@ -48,6 +52,47 @@ type DeleteBannerRequest struct {
 	ID int `json:"id"`
 }
 
+type DeleteBannersRequest struct {
+	FeatureID int `json:"feature_id"`
+	TagID     int `json:"tag_id"`
+}
+
 type DeleteBannerResponse struct {
 	ErrorMessage string `json:"error,omitempty"`
 }
+
+type BannerVersionsRequest struct {
+	BannerID int `json:"banner_id"`
+}
+
+type BannerVersionRequest struct {
+	BannerID int `json:"banner_id"`
+	Version  int `json:"version"`
+}
+
+type RestoreBannerVersionRequest struct {
+	BannerVersionRequest
+}
+
+type ExportBannersRequest struct {
+	FeatureID int `json:"feature_id"`
+	TagID     int `json:"tag_id"`
+}
+
+type ImportBannerLine struct {
+	Line   int
+	Banner models.BannerCommon
+}
+
+type ImportBannersRequest struct {
+	Mode    models.ImportMode
+	DryRun  bool
+	Banners []ImportBannerLine
+	// Errors holds lines rejected before reaching the service, e.g. malformed json
+	Errors []models.ImportLineError
+}
+
+type CachedBannerRequest struct {
+	FeatureID int `json:"feature_id"`
+	TagID     int `json:"tag_id"`
+}
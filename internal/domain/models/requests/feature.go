@@ -0,0 +1,45 @@
+package requests
+
+import (
+	"encoding/json"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+)
+
+type GetFeaturesRequest struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type GetFeatureRequest struct {
+	ID int `json:"id"`
+}
+
+type CreateFeatureRequest struct {
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	ContentSchema json.RawMessage `json:"content_schema"`
+}
+
+type CreateFeatureResponse struct {
+	FeatureID    int    `json:"feature_id,omitempty"`
+	ErrorMessage string `json:"error,omitempty"`
+}
+
+type UpdateFeatureRequest struct {
+	ID          int     `json:"id"`
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	// ContentSchema is left untouched when absent and removed when set to null
+	ContentSchema json.RawMessage `json:"content_schema"`
+}
+
+type ValidateContentRequest struct {
+	FeatureID int                  `json:"feature_id"`
+	Content   models.BannerContent `json:"content"`
+}
+
+type DeleteFeatureRequest struct {
+	ID      int  `json:"id"`
+	Cascade bool `json:"cascade"`
+}
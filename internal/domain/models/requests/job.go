@@ -0,0 +1,9 @@
+package requests
+
+type GetJobRequest struct {
+	ID string `json:"id"`
+}
+
+type JobResponse struct {
+	JobID string `json:"job_id"`
+}
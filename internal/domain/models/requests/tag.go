@@ -0,0 +1,29 @@
+package requests
+
+type GetTagsRequest struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type GetTagRequest struct {
+	ID int `json:"id"`
+}
+
+type CreateTagRequest struct {
+	Name string `json:"name"`
+}
+
+type CreateTagResponse struct {
+	TagID        int    `json:"tag_id,omitempty"`
+	ErrorMessage string `json:"error,omitempty"`
+}
+
+type UpdateTagRequest struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type DeleteTagRequest struct {
+	ID      int  `json:"id"`
+	Cascade bool `json:"cascade"`
+}
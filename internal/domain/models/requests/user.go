@@ -0,0 +1,37 @@
+package requests
+
+type GetUsersRequest struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type CreateUserRequest struct {
+	Name    string `json:"name"`
+	IsAdmin bool   `json:"is_admin"`
+	Tags    []int  `json:"tags"`
+}
+
+type UpdateUserRequest struct {
+	Name    string `json:"name"`
+	IsAdmin *bool  `json:"is_admin"`
+}
+
+type UserTagsRequest struct {
+	Name string `json:"name"`
+	Tags []int  `json:"tags"`
+}
+
+type SetPasswordRequest struct {
+	Name string `json:"name"`
+	// CurrentPassword is required when users change their own password
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+}
+
+type RevokeUserRequest struct {
+	Name string `json:"name"`
+}
+
+type DeleteUserRequest struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,8 @@
+package models
+
+type Tag struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	BannersCount int    `json:"banners_count"`
+	UsersCount   int    `json:"users_count"`
+}
@@ -1,7 +1,17 @@
 package models
 
+import "time"
+
 type User struct {
-	Name    string
-	IsAdmin bool
-	Tags    []int
+	Name    string `json:"name"`
+	IsAdmin bool   `json:"is_admin"`
+	Tags    []int  `json:"tags"`
+}
+
+// Tokens is what a user gets on sign in. The access token expires at ExpiresAt,
+// the refresh token can be exchanged once for a new pair.
+type Tokens struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
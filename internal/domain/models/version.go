@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type BannerVersion struct {
+	BannerID    int           `json:"banner_id"`
+	Version     int           `json:"version"`
+	FeatureID   int           `json:"feature_id"`
+	TagIDS      []int         `json:"tag_ids"`
+	Content     BannerContent `json:"content"`
+	IsActive    bool          `json:"is_active"`
+	ActiveFrom  *time.Time    `json:"active_from,omitempty"`
+	ActiveUntil *time.Time    `json:"active_until,omitempty"`
+	Author      string        `json:"author"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/antsrp/banner_service/internal/domain/models"
 )
@@ -13,14 +14,52 @@ type GetBanner struct {
 
 type GetBannerLimited struct {
 	GetBanner
+	Status models.BannerStatus
 	Limit  int
 	Offset int
 }
 
+type GetBannerVersion struct {
+	BannerID int
+	Version  int
+}
+
+type UpdateBanner struct {
+	Banner models.Banner
+	// ClearActiveFrom and ClearActiveUntil remove the bound, a nil bound in Banner is left untouched
+	ClearActiveFrom  bool
+	ClearActiveUntil bool
+	// Check is called with the locked row before it is changed, an error from it cancels the update
+	Check func(before models.Banner) error
+}
+
+type ImportBanner struct {
+	Line   int
+	Banner models.Banner
+}
+
+type ImportOptions struct {
+	Mode   models.ImportMode
+	DryRun bool
+	Author string
+}
+
 type BannerStorage interface {
-	Create(context.Context, models.Banner) (models.Banner, DatabaseError)
-	Update(context.Context, models.Banner) DatabaseError
+	Create(ctx context.Context, banner models.Banner, author string) (models.Banner, DatabaseError)
+	Update(ctx context.Context, opts UpdateBanner, author string) (models.Banner, DatabaseError)
 	Get(ctx context.Context, opts GetBannerLimited) ([]models.Banner, DatabaseError)
-	GetOne(ctx context.Context, opts GetBanner, userName string) (models.Banner, DatabaseError)
+	GetOne(ctx context.Context, opts GetBanner) (models.Banner, DatabaseError)
+	GetByID(ctx context.Context, id int) (models.Banner, DatabaseError)
 	Delete(ctx context.Context, id int) DatabaseError
+	Count(ctx context.Context, opts GetBanner) (int, DatabaseError)
+	DeleteBatch(ctx context.Context, opts GetBanner, size int) ([]models.Banner, DatabaseError)
+	Changes(ctx context.Context, since time.Time) (models.BannerChanges, DatabaseError)
+	PurgeTombstones(ctx context.Context, before time.Time) DatabaseError
+
+	Iterate(ctx context.Context, opts GetBannerLimited, fn func(models.Banner) error) DatabaseError
+	Import(ctx context.Context, banners []ImportBanner, opts ImportOptions) (models.ImportReport, DatabaseError)
+
+	Versions(ctx context.Context, bannerID int) ([]models.BannerVersion, DatabaseError)
+	Version(ctx context.Context, opts GetBannerVersion) (models.BannerVersion, DatabaseError)
+	Restore(ctx context.Context, opts GetBannerVersion, author string) DatabaseError
 }
@@ -1,12 +1,28 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+)
 
 const (
 	msgNoRowsAffected = "no rows affected"
 	msgEntityNotFound = "no entity found"
 
 	msgUsernameAlreadyExists = "user with name already exists"
+	msgUnknownTags           = "some of tags do not exist"
+	msgBannerConflict        = "banner for feature and tag already exists"
+	msgVersionRefsMissing    = "feature or tags of the version no longer exist"
+
+	msgFeatureAlreadyExists = "feature with name already exists"
+	msgFeatureHasBanners    = "feature still has banners"
+
+	msgTagAlreadyExists = "tag with name already exists"
+	msgTagInUse         = "tag is still assigned to banners or users"
+
+	msgRefreshTokenExpired = "refresh token is expired"
+	msgRefreshTokenReused  = "refresh token was already used"
 )
 
 var (
@@ -14,8 +30,32 @@ var (
 	ErrEntityNotFound = errors.New(msgEntityNotFound)
 
 	ErrUsernameAlreadyExists = errors.New(msgUsernameAlreadyExists)
+	ErrUnknownTags           = errors.New(msgUnknownTags)
+	ErrBannerConflict        = errors.New(msgBannerConflict)
+	ErrVersionRefsMissing    = errors.New(msgVersionRefsMissing)
+
+	ErrFeatureAlreadyExists = errors.New(msgFeatureAlreadyExists)
+	ErrFeatureHasBanners    = errors.New(msgFeatureHasBanners)
+
+	ErrTagAlreadyExists = errors.New(msgTagAlreadyExists)
+	ErrTagInUse         = errors.New(msgTagInUse)
+
+	ErrRefreshTokenExpired = errors.New(msgRefreshTokenExpired)
+	ErrRefreshTokenReused  = errors.New(msgRefreshTokenReused)
 )
 
+type BannerConflictError struct {
+	Conflicts []models.BannerConflict
+}
+
+func (e BannerConflictError) Error() string {
+	return msgBannerConflict
+}
+
+func (e BannerConflictError) Unwrap() error {
+	return ErrBannerConflict
+}
+
 type DatabaseError interface {
 	IsInternal() bool
 	Cause() error
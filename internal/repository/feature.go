@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+)
+
+type GetFeaturesLimited struct {
+	Limit  int
+	Offset int
+}
+
+type UpdateFeature struct {
+	ID          int
+	Name        *string
+	Description *string
+	// ContentSchema is left untouched when nil and removed when it holds json null
+	ContentSchema json.RawMessage
+}
+
+type FeatureStorage interface {
+	Create(context.Context, models.Feature) (models.Feature, DatabaseError)
+	Get(ctx context.Context, opts GetFeaturesLimited) ([]models.Feature, DatabaseError)
+	GetOne(ctx context.Context, id int) (models.Feature, DatabaseError)
+	Update(context.Context, UpdateFeature) DatabaseError
+	// Delete returns the (feature, tag) pairs that lost their banner in the cascade
+	Delete(ctx context.Context, id int, cascade bool) ([]models.BannerKey, DatabaseError)
+}
@@ -23,9 +23,8 @@ func NewBannerStorage(conn *Connection) BannerStorage {
 	}
 }
 
-func (s BannerStorage) Create(ctx context.Context, banner models.Banner) (models.Banner, repository.DatabaseError) {
+func (s BannerStorage) Create(ctx context.Context, banner models.Banner, author string) (models.Banner, repository.DatabaseError) {
 	var result models.Banner
-	//result.ID = id
 	contentData, err := mapper.ToJSON(banner.Content, &mapper.DefaultIndent)
 	if err != nil {
 		return models.Banner{}, NewError("can't present banner's content to json", err)
@@ -36,78 +35,225 @@ func (s BannerStorage) Create(ctx context.Context, banner models.Banner) (models
 		return models.Banner{}, NewError("can't create transaction", err)
 	}
 	defer tx.Rollback(ctx)
-	if err := tx.QueryRow(ctx, `INSERT INTO banners (feature_id, is_active, content) VALUES ($1, $2, $3) RETURNING id;`, banner.FeatureID, banner.IsActive, contentData).
+	if err := checkConflicts(ctx, tx, 0, banner.FeatureID, banner.TagIDS); err != nil {
+		return models.Banner{}, NewError("can't create banner", err)
+	}
+	if err := tx.QueryRow(ctx, `INSERT INTO banners (feature_id, is_active, content, active_from, active_until) VALUES ($1, $2, $3, $4, $5) RETURNING id;`,
+		banner.FeatureID, banner.IsActive, contentData, banner.ActiveFrom, banner.ActiveUntil).
 		Scan(&result.ID); err != nil {
 		return models.Banner{}, NewError("can't create banner", err)
 	}
-	values := make([]string, 0, len(banner.TagIDS))
-	for _, tag := range banner.TagIDS {
-		values = append(values, fmt.Sprintf("(%d, %d)", result.ID, tag))
+	if err := insertTags(ctx, tx, result.ID, banner.FeatureID, banner.TagIDS); err != nil {
+		return models.Banner{}, NewError("can't add tags for banner", s.conflictFromViolation(ctx, err, result.ID, banner.FeatureID, banner.TagIDS))
 	}
-	if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO banners_tags (banner_id, tag_id) VALUES %s`, strings.Join(values, ","))); err != nil {
-		return models.Banner{}, NewError("can't add tags for banner", err)
+	if err := writeVersion(ctx, tx, result.ID, author); err != nil {
+		return models.Banner{}, NewError("can't write banner version", err)
 	}
 
-	tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return models.Banner{}, NewError("can't commit transaction", err)
+	}
 
 	return result, nil
 }
 
-func (s BannerStorage) Update(ctx context.Context, banner models.Banner) repository.DatabaseError {
+func (s BannerStorage) Update(ctx context.Context, opts repository.UpdateBanner, author string) (models.Banner, repository.DatabaseError) {
+	banner := opts.Banner
 	query := `UPDATE banners SET %s WHERE id = $1`
 	errString := "can't update banner"
 
 	tx, err := s.conn.PC.Begin(ctx)
 	if err != nil {
-		return NewError(errString, err)
+		return models.Banner{}, NewError(errString, err)
 	}
 	defer tx.Rollback(ctx)
 
-	setOpts := make([]string, 0, 5)
+	before, err := lockBanner(ctx, tx, banner.ID)
+	if err != nil {
+		return models.Banner{}, NewError(errString, err)
+	}
+	if opts.Check != nil {
+		if err := opts.Check(before); err != nil {
+			return models.Banner{}, NewError(errString, err)
+		}
+	}
+	featureID, tags := before.FeatureID, before.TagIDS
+	if banner.FeatureID != 0 {
+		featureID = banner.FeatureID
+	}
+	if banner.TagIDS != nil {
+		tags = banner.TagIDS
+	}
+	if err := checkConflicts(ctx, tx, banner.ID, featureID, tags); err != nil {
+		return models.Banner{}, NewError(errString, err)
+	}
+
+	args := []any{banner.ID}
+	setOpts := make([]string, 0, 4)
 	if banner.FeatureID != 0 {
-		setOpts = append(setOpts, fmt.Sprintf("feature_id = %d", banner.FeatureID))
+		args = append(args, banner.FeatureID)
+		setOpts = append(setOpts, fmt.Sprintf("feature_id = $%d", len(args)))
 	}
 	if banner.IsActive != nil {
-		setOpts = append(setOpts, fmt.Sprintf("is_active = %t", *banner.IsActive))
+		args = append(args, *banner.IsActive)
+		setOpts = append(setOpts, fmt.Sprintf("is_active = $%d", len(args)))
 	}
 	if banner.Content != nil {
-		setOpts = append(setOpts, fmt.Sprintf("is_active = %v", banner.Content))
+		contentData, err := mapper.ToJSON(banner.Content, &mapper.DefaultIndent)
+		if err != nil {
+			return models.Banner{}, NewError("can't present banner's content to json", err)
+		}
+		args = append(args, contentData)
+		setOpts = append(setOpts, fmt.Sprintf("content = $%d", len(args)))
+	}
+	if banner.ActiveFrom != nil {
+		args = append(args, *banner.ActiveFrom)
+		setOpts = append(setOpts, fmt.Sprintf("active_from = $%d", len(args)))
+	} else if opts.ClearActiveFrom {
+		setOpts = append(setOpts, "active_from = NULL")
+	}
+	if banner.ActiveUntil != nil {
+		args = append(args, *banner.ActiveUntil)
+		setOpts = append(setOpts, fmt.Sprintf("active_until = $%d", len(args)))
+	} else if opts.ClearActiveUntil {
+		setOpts = append(setOpts, "active_until = NULL")
 	}
 	setOpts = append(setOpts, "updated_at = now()")
 	query = fmt.Sprintf(query, strings.Join(setOpts, ","))
 
-	tag, err := tx.Exec(ctx, query, banner.ID)
+	// old tags go first, otherwise moving them to the new feature may collide with tags being dropped
+	if banner.TagIDS != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM banners_tags WHERE banner_id = $1`, banner.ID); err != nil {
+			return models.Banner{}, NewError("can't update tags for banner", err)
+		}
+	}
+	tag, err := tx.Exec(ctx, query, args...)
 	if err != nil {
-		return NewError(errString, err)
+		return models.Banner{}, NewError(errString, s.conflictFromViolation(ctx, err, banner.ID, featureID, tags))
 	}
 	if tag.RowsAffected() == 0 {
-		return NewError(errString, repository.ErrEntityNotFound)
+		return models.Banner{}, NewError(errString, repository.ErrEntityNotFound)
 	}
 
 	if banner.TagIDS != nil {
-		if _, err := tx.Exec(ctx, `DELETE FROM banners_tags WHERE banner_id = $1`, banner.ID); err != nil {
-			return NewError("can't update tags for banner", err)
-		}
-		values := make([]string, 0, len(banner.TagIDS))
-		for _, tag := range banner.TagIDS {
-			values = append(values, fmt.Sprintf("(%d, %d)", banner.ID, tag))
-		}
-		if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO banners_tags (banner_id, tag_id) VALUES %s`, strings.Join(values, ","))); err != nil {
-			return NewError("can't add tags for banner", err)
+		if err := insertTags(ctx, tx, banner.ID, featureID, banner.TagIDS); err != nil {
+			return models.Banner{}, NewError("can't add tags for banner", s.conflictFromViolation(ctx, err, banner.ID, featureID, tags))
 		}
 	}
+	if err := writeVersion(ctx, tx, banner.ID, author); err != nil {
+		return models.Banner{}, NewError("can't write banner version", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Banner{}, NewError("can't commit transaction", err)
+	}
+	return before, nil
+}
 
-	tx.Commit(ctx)
+// touchBanners marks banners changed outside of Update as updated and writes their new versions.
+func touchBanners(ctx context.Context, tx pgx.Tx, ids []int, author string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE banners SET updated_at = now() WHERE id = ANY($1)`, ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := writeVersion(ctx, tx, id, author); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func insertTags(ctx context.Context, tx pgx.Tx, bannerID, featureID int, tags []int) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", bannerID, featureID, tag))
+	}
+	_, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO banners_tags (banner_id, feature_id, tag_id) VALUES %s`, strings.Join(values, ",")))
+	return err
+}
+
+// liveCondition selects banners whose activation window contains the current moment.
+const liveCondition = "(active_from IS NULL OR active_from <= now()) AND (active_until IS NULL OR active_until > now())"
+
+func statusCondition(status models.BannerStatus) string {
+	switch status {
+	case models.BannerStatusScheduled:
+		return "active_from > now()"
+	case models.BannerStatusLive:
+		return liveCondition
+	case models.BannerStatusExpired:
+		return "active_until <= now()"
+	}
+	return ""
+}
+
+func scanBanner(row pgx.Row) (models.Banner, error) {
+	banner := models.Banner{
+		BannerCommon: models.BannerCommon{
+			Content: make(models.BannerContent),
+		},
+	}
+	var (
+		createdAt, updatedAt    sql.NullTime
+		activeFrom, activeUntil sql.NullTime
+		isActive                sql.NullBool
+	)
+	if err := row.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &createdAt, &updatedAt, &isActive, &activeFrom, &activeUntil, &banner.TagIDS); err != nil {
+		return models.Banner{}, err
+	}
+	if createdAt.Valid {
+		banner.CreatedAt = createdAt.Time
+	}
+	if updatedAt.Valid {
+		banner.UpdatedAt = updatedAt.Time
+	}
+	if isActive.Valid {
+		banner.IsActive = &isActive.Bool
+	}
+	if activeFrom.Valid {
+		banner.ActiveFrom = &activeFrom.Time
+	}
+	if activeUntil.Valid {
+		banner.ActiveUntil = &activeUntil.Time
+	}
+	return banner, nil
+}
+
 func (s BannerStorage) Get(ctx context.Context, opts repository.GetBannerLimited) ([]models.Banner, repository.DatabaseError) {
-	var whereConditions, limitConditions []string
+	var banners []models.Banner
+	if err := s.Iterate(ctx, opts, func(banner models.Banner) error {
+		banners = append(banners, banner)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return banners, nil
+}
+
+// filterConditions selects banners of the feature and/or the tag, banners table is aliased as b.
+func filterConditions(opts repository.GetBanner) []string {
+	var conditions []string
 	if opts.FeatureID != 0 {
-		whereConditions = append(whereConditions, fmt.Sprintf("feature_id = %d", opts.FeatureID))
+		conditions = append(conditions, fmt.Sprintf("b.feature_id = %d", opts.FeatureID))
 	}
 	if opts.TagID != 0 {
-		whereConditions = append(whereConditions, fmt.Sprintf("tag_id = %d", opts.TagID))
+		conditions = append(conditions, fmt.Sprintf("EXISTS(SELECT 1 FROM banners_tags WHERE banner_id = b.id AND tag_id = %d)", opts.TagID))
+	}
+	return conditions
+}
+
+// Iterate calls fn for every banner matching opts, row by row, without loading them all into memory.
+func (s BannerStorage) Iterate(ctx context.Context, opts repository.GetBannerLimited, fn func(models.Banner) error) repository.DatabaseError {
+	var limitConditions []string
+	whereConditions := filterConditions(opts.GetBanner)
+	if cond := statusCondition(opts.Status); cond != "" {
+		whereConditions = append(whereConditions, cond)
 	}
 	if opts.Limit > 0 {
 		limitConditions = append(limitConditions, fmt.Sprintf("LIMIT %d", opts.Limit))
@@ -120,80 +266,72 @@ func (s BannerStorage) Get(ctx context.Context, opts repository.GetBannerLimited
 		wheres = fmt.Sprintf("WHERE %s", strings.Join(whereConditions, " AND "))
 	}
 
-	query := fmt.Sprintf(`SELECT banners.id, feature_id, content, created_at, updated_at, is_active, array_agg(tag_id) FROM banners
-	JOIN banners_tags ON banners.id = banners_tags.banner_id
+	query := fmt.Sprintf(`SELECT b.id, feature_id, content, created_at, updated_at, is_active, active_from, active_until,
+	ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id) FROM banners b
 	%s
-	GROUP BY(banners.id)
+	ORDER BY b.id
 	%s`, wheres, strings.Join(limitConditions, " "))
 
 	rows, err := s.conn.PC.Query(ctx, query)
 	if err != nil {
-		return nil, NewError("can't get banners from database", err)
+		return NewError("can't get banners from database", err)
 	}
 	defer rows.Close()
-	var banners []models.Banner
 	for rows.Next() {
-		banner := models.Banner{
-			BannerCommon: models.BannerCommon{
-				Content: make(models.BannerContent),
-			},
-		}
-		var (
-			createdAt, updatedAt sql.NullTime
-			isActive             sql.NullBool
-		)
-		if err := rows.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &createdAt, &updatedAt, &isActive, &banner.TagIDS); err != nil {
-			return nil, NewError("can't scan banner from row", err)
+		banner, err := scanBanner(rows)
+		if err != nil {
+			return NewError("can't scan banner from row", err)
 		}
-		if createdAt.Valid {
-			banner.CreatedAt = createdAt.Time
+		if err := fn(banner); err != nil {
+			return NewError("can't handle banner", err)
 		}
-		if updatedAt.Valid {
-			banner.UpdatedAt = updatedAt.Time
-		}
-		if isActive.Valid {
-			banner.IsActive = &isActive.Bool
-		}
-		banners = append(banners, banner)
+	}
+	if err := rows.Err(); err != nil {
+		return NewError("can't read banners", err)
 	}
 
-	return banners, nil
+	return nil
 }
-func (s BannerStorage) GetOne(ctx context.Context, opts repository.GetBanner, userName string) (models.Banner, repository.DatabaseError) {
+func (s BannerStorage) GetOne(ctx context.Context, opts repository.GetBanner) (models.Banner, repository.DatabaseError) {
 	/*query := `SELECT banners.id, feature_id, content, created_at, updated_at, is_active, array_agg(tag_id) AS tags FROM banners
 	JOIN banners_tags ON banners.id = banners_tags.banner_id
 	WHERE feature_id = $1
 	GROUP BY (banners.id) HAVING $2=ANY(array_agg(tag_id))` */
 
-	query := `SELECT b.id, feature_id, content, created_at, updated_at, is_active, array_agg(tag_id) AS tags FROM banners b 
-	JOIN banners_tags bt ON b.id = bt.banner_id WHERE EXISTS 
-	(SELECT u.id from users u JOIN users_tags ut ON u.id = ut.user_id where name = $1 AND (is_admin OR tag_id = $2))
-	AND feature_id = $3 
-	GROUP BY(b.id)`
+	query := `SELECT b.id, b.feature_id, content, created_at, updated_at, is_active, active_from, active_until,
+	ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id) AS tags FROM banners b 
+	JOIN banners_tags bt ON b.id = bt.banner_id
+	WHERE bt.feature_id = $1 AND bt.tag_id = $2 AND ` + liveCondition
 
-	banner := models.Banner{
-		BannerCommon: models.BannerCommon{
-			Content: make(models.BannerContent),
-		},
-	}
-	var (
-		createdAt, updatedAt sql.NullTime
-		isActive             sql.NullBool
-	)
-	if err := s.conn.PC.QueryRow(ctx, query, userName, opts.TagID, opts.FeatureID).Scan(&banner.ID, &banner.FeatureID, &banner.Content, &createdAt, &updatedAt, &isActive, &banner.TagIDS); err != nil {
+	banner, err := scanBanner(s.conn.PC.QueryRow(ctx, query, opts.FeatureID, opts.TagID))
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			err = repository.ErrEntityNotFound
 		}
 		return models.Banner{}, NewError("can't scan banner from row", err)
 	}
-	if createdAt.Valid {
-		banner.CreatedAt = createdAt.Time
-	}
-	if updatedAt.Valid {
-		banner.UpdatedAt = updatedAt.Time
+	return banner, nil
+}
+
+const bannerByIDQuery = `SELECT b.id, feature_id, content, created_at, updated_at, is_active, active_from, active_until,
+	ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id) FROM banners b WHERE b.id = $1`
+
+// lockBanner reads the banner and keeps its row locked until the transaction ends.
+func lockBanner(ctx context.Context, tx pgx.Tx, id int) (models.Banner, error) {
+	banner, err := scanBanner(tx.QueryRow(ctx, bannerByIDQuery+` FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = repository.ErrEntityNotFound
 	}
-	if isActive.Valid {
-		banner.IsActive = &isActive.Bool
+	return banner, err
+}
+
+func (s BannerStorage) GetByID(ctx context.Context, id int) (models.Banner, repository.DatabaseError) {
+	banner, err := scanBanner(s.conn.PC.QueryRow(ctx, bannerByIDQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return models.Banner{}, NewError("can't scan banner from row", err)
 	}
 	return banner, nil
 }
@@ -210,4 +348,47 @@ func (s BannerStorage) Delete(ctx context.Context, id int) repository.DatabaseEr
 	return nil
 }
 
+func (s BannerStorage) Count(ctx context.Context, opts repository.GetBanner) (int, repository.DatabaseError) {
+	query := `SELECT count(*) FROM banners b`
+	if conditions := filterConditions(opts); len(conditions) != 0 {
+		query = fmt.Sprintf("%s WHERE %s", query, strings.Join(conditions, " AND "))
+	}
+	var count int
+	if err := s.conn.PC.QueryRow(ctx, query).Scan(&count); err != nil {
+		return 0, NewError("can't count banners", err)
+	}
+	return count, nil
+}
+
+// DeleteBatch deletes up to size banners matching opts and returns them with the tags they had.
+// Banners locked by other transactions are skipped, an empty result means nothing is left to delete.
+func (s BannerStorage) DeleteBatch(ctx context.Context, opts repository.GetBanner, size int) ([]models.Banner, repository.DatabaseError) {
+	conditions := filterConditions(opts)
+	if len(conditions) == 0 {
+		return nil, NewError("can't delete banners", fmt.Errorf("neither feature nor tag is set"))
+	}
+	query := fmt.Sprintf(`WITH batch AS (SELECT b.id FROM banners b WHERE %s ORDER BY b.id LIMIT $1 FOR UPDATE SKIP LOCKED)
+	DELETE FROM banners b USING batch WHERE b.id = batch.id
+	RETURNING b.id, b.feature_id, content, created_at, updated_at, is_active, active_from, active_until,
+	ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id)`, strings.Join(conditions, " AND "))
+
+	rows, err := s.conn.PC.Query(ctx, query, size)
+	if err != nil {
+		return nil, NewError("can't delete banners", err)
+	}
+	defer rows.Close()
+	var banners []models.Banner
+	for rows.Next() {
+		banner, err := scanBanner(rows)
+		if err != nil {
+			return nil, NewError("can't scan banner from row", err)
+		}
+		banners = append(banners, banner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't delete banners", err)
+	}
+	return banners, nil
+}
+
 var _ repository.BannerStorage = BannerStorage{}
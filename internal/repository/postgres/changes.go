@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// Changes reads everything that happened to banners after since from one snapshot. Until is the time
+// of that snapshot, transactions still running at that moment may commit with earlier timestamps,
+// so the next call should start a bit before it. Zero since returns all banners.
+func (s BannerStorage) Changes(ctx context.Context, since time.Time) (models.BannerChanges, repository.DatabaseError) {
+	tx, err := s.conn.PC.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return models.BannerChanges{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var changes models.BannerChanges
+	if err := tx.QueryRow(ctx, `SELECT now()`).Scan(&changes.Until); err != nil {
+		return models.BannerChanges{}, NewError("can't get current time", err)
+	}
+
+	rows, err := tx.Query(ctx, `SELECT feature_id, tag_id FROM banners_tags_tombstones WHERE deleted_at > $1 ORDER BY id`, since)
+	if err != nil {
+		return models.BannerChanges{}, NewError("can't get removed banners", err)
+	}
+	changes.Removed, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.BannerKey])
+	if err != nil {
+		return models.BannerChanges{}, NewError("can't scan removed banner from row", err)
+	}
+
+	// a banner also changes its status when its activation window opens or closes
+	rows, err = tx.Query(ctx, `SELECT b.id, feature_id, content, created_at, updated_at, is_active, active_from, active_until,
+	ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id) FROM banners b
+	WHERE updated_at > $1 OR active_from > $1 AND active_from <= $2 OR active_until > $1 AND active_until <= $2
+	ORDER BY b.id`, since, changes.Until)
+	if err != nil {
+		return models.BannerChanges{}, NewError("can't get changed banners", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		banner, err := scanBanner(rows)
+		if err != nil {
+			return models.BannerChanges{}, NewError("can't scan banner from row", err)
+		}
+		changes.Changed = append(changes.Changed, banner)
+	}
+	if err := rows.Err(); err != nil {
+		return models.BannerChanges{}, NewError("can't get changed banners", err)
+	}
+	return changes, nil
+}
+
+func (s BannerStorage) PurgeTombstones(ctx context.Context, before time.Time) repository.DatabaseError {
+	if _, err := s.conn.PC.Exec(ctx, `DELETE FROM banners_tags_tombstones WHERE deleted_at < $1`, before); err != nil {
+		return NewError("can't delete old tombstones", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const featureTagUniqueConstraint = "banners_tags_feature_id_tag_id_key"
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// findConflicts returns the (feature, tag) pairs already taken by banners other than bannerID.
+func findConflicts(ctx context.Context, q querier, bannerID, featureID int, tags []int) ([]models.BannerConflict, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	rows, err := q.Query(ctx, `SELECT banner_id, feature_id, tag_id FROM banners_tags
+	WHERE feature_id = $1 AND tag_id = ANY($2) AND banner_id <> $3
+	ORDER BY banner_id, tag_id`, featureID, tags, bannerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []models.BannerConflict
+	for rows.Next() {
+		var c models.BannerConflict
+		if err := rows.Scan(&c.BannerID, &c.FeatureID, &c.TagID); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// checkConflicts fails with repository.BannerConflictError when the banner would share
+// a (feature, tag) pair with another banner.
+func checkConflicts(ctx context.Context, q querier, bannerID, featureID int, tags []int) error {
+	conflicts, err := findConflicts(ctx, q, bannerID, featureID, tags)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) != 0 {
+		return repository.BannerConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// conflictFromViolation turns a unique violation raced past checkConflicts into a
+// repository.BannerConflictError, reading the conflicting rows outside of the failed transaction.
+func (s BannerStorage) conflictFromViolation(ctx context.Context, err error, bannerID, featureID int, tags []int) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation || pgErr.ConstraintName != featureTagUniqueConstraint {
+		return err
+	}
+	conflicts, qErr := findConflicts(ctx, s.conn.PC, bannerID, featureID, tags)
+	if qErr != nil {
+		s.conn.logger.Error("can't find conflicting banners: %v", qErr.Error())
+	}
+	return repository.BannerConflictError{Conflicts: conflicts}
+}
+
+// bannerState reads the feature and tags the banner currently has and locks its row.
+func bannerState(ctx context.Context, tx pgx.Tx, bannerID int) (int, []int, error) {
+	var (
+		featureID int
+		tags      []int
+	)
+	err := tx.QueryRow(ctx, `SELECT feature_id, ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id)
+	FROM banners b WHERE id = $1 FOR UPDATE`, bannerID).Scan(&featureID, &tags)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = repository.ErrEntityNotFound
+	}
+	return featureID, tags, err
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type FeatureStorage struct {
+	conn *Connection
+}
+
+func NewFeatureStorage(conn *Connection) FeatureStorage {
+	return FeatureStorage{
+		conn: conn,
+	}
+}
+
+func featureNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return repository.ErrFeatureAlreadyExists
+	}
+	return err
+}
+
+// featureInUse maps a banner referencing the feature to the same error the pre-check gives.
+func featureInUse(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		return repository.ErrFeatureHasBanners
+	}
+	return err
+}
+
+// nullableJSON maps both missing and json null documents to sql NULL.
+func nullableJSON(data json.RawMessage) any {
+	if data == nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+func scanFeature(row pgx.Row) (models.Feature, error) {
+	var (
+		feature     models.Feature
+		description sql.NullString
+		schema      []byte
+	)
+	if err := row.Scan(&feature.ID, &feature.Name, &description, &schema, &feature.BannersCount); err != nil {
+		return models.Feature{}, err
+	}
+	if description.Valid {
+		feature.Description = description.String
+	}
+	if schema != nil {
+		feature.ContentSchema = schema
+	}
+	return feature, nil
+}
+
+func (s FeatureStorage) Create(ctx context.Context, feature models.Feature) (models.Feature, repository.DatabaseError) {
+	if err := s.conn.PC.QueryRow(ctx, `INSERT INTO features (name, description, content_schema) VALUES ($1, $2, $3) RETURNING id;`,
+		feature.Name, feature.Description, nullableJSON(feature.ContentSchema)).
+		Scan(&feature.ID); err != nil {
+		return models.Feature{}, NewError("can't create feature", featureNameTaken(err))
+	}
+	return feature, nil
+}
+
+func (s FeatureStorage) Get(ctx context.Context, opts repository.GetFeaturesLimited) ([]models.Feature, repository.DatabaseError) {
+	var limitConditions []string
+	if opts.Limit > 0 {
+		limitConditions = append(limitConditions, fmt.Sprintf("LIMIT %d", opts.Limit))
+	}
+	if opts.Offset > 0 {
+		limitConditions = append(limitConditions, fmt.Sprintf("OFFSET %d", opts.Offset))
+	}
+	query := fmt.Sprintf(`SELECT f.id, f.name, f.description, f.content_schema, (SELECT COUNT(*) FROM banners WHERE feature_id = f.id) FROM features f
+	ORDER BY f.id
+	%s`, strings.Join(limitConditions, " "))
+
+	rows, err := s.conn.PC.Query(ctx, query)
+	if err != nil {
+		return nil, NewError("can't get features from database", err)
+	}
+	defer rows.Close()
+	features := make([]models.Feature, 0)
+	for rows.Next() {
+		feature, err := scanFeature(rows)
+		if err != nil {
+			return nil, NewError("can't scan feature from row", err)
+		}
+		features = append(features, feature)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't read features", err)
+	}
+	return features, nil
+}
+
+func (s FeatureStorage) GetOne(ctx context.Context, id int) (models.Feature, repository.DatabaseError) {
+	feature, err := scanFeature(s.conn.PC.QueryRow(ctx, `SELECT f.id, f.name, f.description, f.content_schema, (SELECT COUNT(*) FROM banners WHERE feature_id = f.id)
+	FROM features f WHERE f.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return models.Feature{}, NewError("can't scan feature from row", err)
+	}
+	return feature, nil
+}
+
+func (s FeatureStorage) Update(ctx context.Context, opts repository.UpdateFeature) repository.DatabaseError {
+	errString := fmt.Sprintf("can't update feature with id %d", opts.ID)
+	args := []any{opts.ID}
+	setOpts := make([]string, 0, 2)
+	if opts.Name != nil {
+		args = append(args, *opts.Name)
+		setOpts = append(setOpts, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if opts.Description != nil {
+		args = append(args, *opts.Description)
+		setOpts = append(setOpts, fmt.Sprintf("description = $%d", len(args)))
+	}
+	if opts.ContentSchema != nil {
+		args = append(args, nullableJSON(opts.ContentSchema))
+		setOpts = append(setOpts, fmt.Sprintf("content_schema = $%d", len(args)))
+	}
+	if len(setOpts) == 0 {
+		setOpts = append(setOpts, "id = id")
+	}
+
+	tag, err := s.conn.PC.Exec(ctx, fmt.Sprintf(`UPDATE features SET %s WHERE id = $1`, strings.Join(setOpts, ",")), args...)
+	if err != nil {
+		return NewError(errString, featureNameTaken(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(errString, repository.ErrEntityNotFound)
+	}
+	return nil
+}
+
+func (s FeatureStorage) Delete(ctx context.Context, id int, cascade bool) ([]models.BannerKey, repository.DatabaseError) {
+	errString := fmt.Sprintf("can't delete feature with id %d", id)
+
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return nil, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the lock holds off banners being created for the feature until it is gone
+	if err := tx.QueryRow(ctx, `SELECT id FROM features WHERE id = $1 FOR UPDATE`, id).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return nil, NewError(errString, err)
+	}
+	var removed []models.BannerKey
+	if cascade {
+		rows, err := tx.Query(ctx, `DELETE FROM banners_tags WHERE feature_id = $1 RETURNING feature_id, tag_id`, id)
+		if err != nil {
+			return nil, NewError(errString, err)
+		}
+		if removed, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.BannerKey]); err != nil {
+			return nil, NewError(errString, err)
+		}
+		// unlike the tag cascade nothing is left to version: the history of the banners stays in
+		// banners_versions, and the pairs they held are recorded as tombstones by the banners_tags trigger
+		if _, err := tx.Exec(ctx, `DELETE FROM banners WHERE feature_id = $1`, id); err != nil {
+			return nil, NewError(errString, err)
+		}
+	} else {
+		var hasBanners bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM banners WHERE feature_id = $1)`, id).Scan(&hasBanners); err != nil {
+			return nil, NewError(errString, err)
+		}
+		if hasBanners {
+			return nil, NewError(errString, repository.ErrFeatureHasBanners)
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM features WHERE id = $1`, id)
+	if err != nil {
+		return nil, NewError(errString, featureInUse(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, NewError(errString, repository.ErrEntityNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, NewError("can't commit transaction", err)
+	}
+	return removed, nil
+}
+
+var _ repository.FeatureStorage = FeatureStorage{}
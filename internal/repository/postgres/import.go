@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	mapper "github.com/antsrp/banner_service/pkg/presenters"
+	"github.com/jackc/pgx/v5"
+)
+
+type importAction int
+
+const (
+	importCreated importAction = iota
+	importUpdated
+	importSkipped
+)
+
+// Import writes all banners in one transaction. Every banner runs in its own savepoint,
+// so a failed line is reported and the rest are still checked; the transaction is committed
+// only when no line failed and opts.DryRun is not set.
+func (s BannerStorage) Import(ctx context.Context, banners []repository.ImportBanner, opts repository.ImportOptions) (models.ImportReport, repository.DatabaseError) {
+	report := models.ImportReport{
+		Mode:   opts.Mode,
+		DryRun: opts.DryRun,
+		Errors: []models.ImportLineError{},
+	}
+
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return models.ImportReport{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if opts.Mode != models.ImportModeSkipExisting {
+		if err := lockImported(ctx, tx, banners); err != nil {
+			return models.ImportReport{}, NewError("can't lock existing banners", err)
+		}
+	}
+
+	for _, banner := range banners {
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return models.ImportReport{}, NewError("can't create savepoint", err)
+		}
+		action, affected, err := importOne(ctx, sp, banner.Banner, opts)
+		if err != nil {
+			if rbErr := sp.Rollback(ctx); rbErr != nil {
+				return models.ImportReport{}, NewError("can't rollback to savepoint", rbErr)
+			}
+			report.Errors = append(report.Errors, models.ImportLineError{Line: banner.Line, Error: err.Error()})
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return models.ImportReport{}, NewError("can't release savepoint", err)
+		}
+		report.Affected = append(report.Affected, affected...)
+		switch action {
+		case importCreated:
+			report.Created++
+		case importUpdated:
+			report.Updated++
+		case importSkipped:
+			report.Skipped++
+		}
+	}
+
+	if len(report.Errors) != 0 || opts.DryRun {
+		return report, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.ImportReport{}, NewError("can't commit transaction", err)
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// lockImported locks the existing banners the lines match, all at once and in the order of their ids,
+// so two imports touching the same banners wait for each other instead of deadlocking.
+func lockImported(ctx context.Context, tx pgx.Tx, banners []repository.ImportBanner) error {
+	var features, tags []int
+	for _, banner := range banners {
+		for _, tag := range banner.Banner.TagIDS {
+			features = append(features, banner.Banner.FeatureID)
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `SELECT id FROM banners WHERE id IN (SELECT bt.banner_id FROM banners_tags bt
+		JOIN unnest($1::int[], $2::int[]) AS p(feature_id, tag_id) ON bt.feature_id = p.feature_id AND bt.tag_id = p.tag_id)
+	ORDER BY id FOR UPDATE`, features, tags)
+	return err
+}
+
+// importOne matches the banner to an existing one by its (feature, tag) pairs.
+// It returns the pairs the written banner had before and has after the line.
+func importOne(ctx context.Context, tx pgx.Tx, banner models.Banner, opts repository.ImportOptions) (importAction, []models.BannerKey, error) {
+	conflicts, err := findConflicts(ctx, tx, 0, banner.FeatureID, banner.TagIDS)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't find existing banner: %w", err)
+	}
+	var ids []int
+	for _, c := range conflicts {
+		if !slices.Contains(ids, c.BannerID) {
+			ids = append(ids, c.BannerID)
+		}
+	}
+	if len(ids) > 1 {
+		return 0, nil, fmt.Errorf("tags of the banner are taken by several banners: %v", ids)
+	}
+	existing := 0
+	if len(ids) == 1 {
+		existing = ids[0]
+	}
+
+	contentData, err := mapper.ToJSON(banner.Content, &mapper.DefaultIndent)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't present banner's content to json: %w", err)
+	}
+
+	affected := make([]models.BannerKey, 0, len(banner.TagIDS))
+	for _, tag := range banner.TagIDS {
+		affected = append(affected, models.BannerKey{FeatureID: banner.FeatureID, TagID: tag})
+	}
+
+	if existing == 0 {
+		var id int
+		if err := tx.QueryRow(ctx, `INSERT INTO banners (feature_id, is_active, content, active_from, active_until) VALUES ($1, $2, $3, $4, $5) RETURNING id;`,
+			banner.FeatureID, banner.IsActive, contentData, banner.ActiveFrom, banner.ActiveUntil).Scan(&id); err != nil {
+			return 0, nil, fmt.Errorf("can't create banner: %w", err)
+		}
+		if err := insertTags(ctx, tx, id, banner.FeatureID, banner.TagIDS); err != nil {
+			return 0, nil, fmt.Errorf("can't add tags for banner: %w", unknownTags(err))
+		}
+		if err := writeVersion(ctx, tx, id, opts.Author); err != nil {
+			return 0, nil, fmt.Errorf("can't write banner version: %w", err)
+		}
+		return importCreated, affected, nil
+	}
+
+	if opts.Mode == models.ImportModeSkipExisting {
+		return importSkipped, nil, nil
+	}
+	// the banner is changed the way Update changes it, under the lock; it is usually taken by lockImported already
+	if _, err := lockBanner(ctx, tx, existing); err != nil {
+		return 0, nil, fmt.Errorf("can't lock banner %d: %w", existing, err)
+	}
+
+	rows, err := tx.Query(ctx, `DELETE FROM banners_tags WHERE banner_id = $1 RETURNING feature_id, tag_id`, existing)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't update tags for banner: %w", err)
+	}
+	previous, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.BannerKey])
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't update tags for banner: %w", err)
+	}
+	// the line is matched by some of its tags, the banner's other tags would be dropped without a word
+	var dropped []int
+	for _, key := range previous {
+		if !slices.Contains(banner.TagIDS, key.TagID) {
+			dropped = append(dropped, key.TagID)
+		}
+	}
+	if len(dropped) != 0 {
+		slices.Sort(dropped)
+		return 0, nil, fmt.Errorf("banner %d also has tags %v missing from the line, list all of its tags or change them separately", existing, dropped)
+	}
+	affected = append(affected, previous...)
+	if _, err := tx.Exec(ctx, `UPDATE banners SET is_active = $2, content = $3, active_from = $4, active_until = $5, updated_at = now() WHERE id = $1`,
+		existing, banner.IsActive, contentData, banner.ActiveFrom, banner.ActiveUntil); err != nil {
+		return 0, nil, fmt.Errorf("can't update banner %d: %w", existing, err)
+	}
+	if err := insertTags(ctx, tx, existing, banner.FeatureID, banner.TagIDS); err != nil {
+		return 0, nil, fmt.Errorf("can't add tags for banner: %w", unknownTags(err))
+	}
+	if err := writeVersion(ctx, tx, existing, opts.Author); err != nil {
+		return 0, nil, fmt.Errorf("can't write banner version: %w", err)
+	}
+	return importUpdated, affected, nil
+}
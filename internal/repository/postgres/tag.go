@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type TagStorage struct {
+	conn *Connection
+}
+
+func NewTagStorage(conn *Connection) TagStorage {
+	return TagStorage{
+		conn: conn,
+	}
+}
+
+const tagColumns = `t.id, t.name,
+	(SELECT COUNT(*) FROM banners_tags WHERE tag_id = t.id),
+	(SELECT COUNT(*) FROM users_tags WHERE tag_id = t.id)`
+
+func tagNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return repository.ErrTagAlreadyExists
+	}
+	return err
+}
+
+// tagInUse maps a banner or user referencing the tag to the same error the pre-check gives.
+func tagInUse(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		return repository.ErrTagInUse
+	}
+	return err
+}
+
+func scanTag(row pgx.Row) (models.Tag, error) {
+	var tag models.Tag
+	if err := row.Scan(&tag.ID, &tag.Name, &tag.BannersCount, &tag.UsersCount); err != nil {
+		return models.Tag{}, err
+	}
+	return tag, nil
+}
+
+func (s TagStorage) Create(ctx context.Context, tag models.Tag) (models.Tag, repository.DatabaseError) {
+	if err := s.conn.PC.QueryRow(ctx, `INSERT INTO tags (name) VALUES ($1) RETURNING id;`, tag.Name).Scan(&tag.ID); err != nil {
+		return models.Tag{}, NewError("can't create tag", tagNameTaken(err))
+	}
+	return tag, nil
+}
+
+func (s TagStorage) Get(ctx context.Context, opts repository.GetTagsLimited) ([]models.Tag, repository.DatabaseError) {
+	var limitConditions []string
+	if opts.Limit > 0 {
+		limitConditions = append(limitConditions, fmt.Sprintf("LIMIT %d", opts.Limit))
+	}
+	if opts.Offset > 0 {
+		limitConditions = append(limitConditions, fmt.Sprintf("OFFSET %d", opts.Offset))
+	}
+	query := fmt.Sprintf(`SELECT %s FROM tags t
+	ORDER BY t.id
+	%s`, tagColumns, strings.Join(limitConditions, " "))
+
+	rows, err := s.conn.PC.Query(ctx, query)
+	if err != nil {
+		return nil, NewError("can't get tags from database", err)
+	}
+	defer rows.Close()
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, NewError("can't scan tag from row", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't read tags", err)
+	}
+	return tags, nil
+}
+
+func (s TagStorage) GetOne(ctx context.Context, id int) (models.Tag, repository.DatabaseError) {
+	tag, err := scanTag(s.conn.PC.QueryRow(ctx, fmt.Sprintf(`SELECT %s FROM tags t WHERE t.id = $1`, tagColumns), id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return models.Tag{}, NewError("can't scan tag from row", err)
+	}
+	return tag, nil
+}
+
+func (s TagStorage) Rename(ctx context.Context, id int, name string) repository.DatabaseError {
+	errString := fmt.Sprintf("can't rename tag with id %d", id)
+	tag, err := s.conn.PC.Exec(ctx, `UPDATE tags SET name = $2 WHERE id = $1`, id, name)
+	if err != nil {
+		return NewError(errString, tagNameTaken(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(errString, repository.ErrEntityNotFound)
+	}
+	return nil
+}
+
+func (s TagStorage) Delete(ctx context.Context, id int, cascade bool, author string) ([]models.BannerKey, repository.DatabaseError) {
+	errString := fmt.Sprintf("can't delete tag with id %d", id)
+
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return nil, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the lock holds off the tag being assigned until it is gone
+	if err := tx.QueryRow(ctx, `SELECT id FROM tags WHERE id = $1 FOR UPDATE`, id).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return nil, NewError(errString, err)
+	}
+	var removed []models.BannerKey
+	if cascade {
+		// banners losing the tag are changed the way Update changes them: locked, touched and versioned
+		rows, err := tx.Query(ctx, `SELECT id FROM banners WHERE id IN (SELECT banner_id FROM banners_tags WHERE tag_id = $1)
+		ORDER BY id FOR UPDATE`, id)
+		if err != nil {
+			return nil, NewError(errString, err)
+		}
+		banners, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return nil, NewError(errString, err)
+		}
+		rows, err = tx.Query(ctx, `DELETE FROM banners_tags WHERE tag_id = $1 RETURNING feature_id, tag_id`, id)
+		if err != nil {
+			return nil, NewError(errString, err)
+		}
+		if removed, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.BannerKey]); err != nil {
+			return nil, NewError(errString, err)
+		}
+		// a banner left without tags can't be served anymore, it is deleted as the feature cascade
+		// deletes banners; its history stays and still holds the tag
+		rows, err = tx.Query(ctx, `DELETE FROM banners b WHERE id = ANY($1)
+		AND NOT EXISTS (SELECT 1 FROM banners_tags WHERE banner_id = b.id) RETURNING id`, banners)
+		if err != nil {
+			return nil, NewError(errString, err)
+		}
+		orphans, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return nil, NewError(errString, err)
+		}
+		banners = slices.DeleteFunc(banners, func(id int) bool { return slices.Contains(orphans, id) })
+		if err := touchBanners(ctx, tx, banners, author); err != nil {
+			return nil, NewError("can't write banner versions", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM users_tags WHERE tag_id = $1`, id); err != nil {
+			return nil, NewError(errString, err)
+		}
+	} else {
+		var inUse bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM banners_tags WHERE tag_id = $1)
+		OR EXISTS(SELECT 1 FROM users_tags WHERE tag_id = $1)`, id).Scan(&inUse); err != nil {
+			return nil, NewError(errString, err)
+		}
+		if inUse {
+			return nil, NewError(errString, repository.ErrTagInUse)
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return nil, NewError(errString, tagInUse(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, NewError(errString, repository.ErrEntityNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, NewError("can't commit transaction", err)
+	}
+	return removed, nil
+}
+
+var _ repository.TagStorage = TagStorage{}
@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s UserStorage) AddRefreshToken(ctx context.Context, token repository.RefreshToken) repository.DatabaseError {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// expired tokens of the user are of no use even for reuse detection
+	if _, err := tx.Exec(ctx, `DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < now()`, token.UserID); err != nil {
+		return NewError("can't delete expired refresh tokens", err)
+	}
+	if err := insertRefreshToken(ctx, tx, token); err != nil {
+		return NewError("can't add refresh token to user", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
+	return nil
+}
+
+func (s UserStorage) RotateRefreshToken(ctx context.Context, hash string, next repository.RefreshToken) (repository.UserWithToken, repository.DatabaseError) {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return repository.UserWithToken{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id        int64
+		expiresAt time.Time
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+	if err := tx.QueryRow(ctx, `SELECT id, user_id, family, expires_at, used_at, revoked_at FROM refresh_tokens
+	WHERE token_hash = $1 FOR UPDATE`, hash).Scan(&id, &next.UserID, &next.Family, &expiresAt, &usedAt, &revokedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return repository.UserWithToken{}, NewError("can't find refresh token", err)
+	}
+
+	switch {
+	case revokedAt.Valid:
+		return repository.UserWithToken{}, NewError("refresh token is revoked", repository.ErrEntityNotFound)
+	case usedAt.Valid:
+		// somebody holds a copy of the token, so nothing issued in its family can be trusted
+		if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE family = $1 AND revoked_at IS NULL`, next.Family); err != nil {
+			return repository.UserWithToken{}, NewError("can't revoke refresh token family", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return repository.UserWithToken{}, NewError("can't commit transaction", err)
+		}
+		return repository.UserWithToken{}, NewError("can't rotate refresh token", repository.ErrRefreshTokenReused)
+	case expiresAt.Before(time.Now()):
+		return repository.UserWithToken{}, NewError("can't rotate refresh token", repository.ErrRefreshTokenExpired)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = now() WHERE id = $1`, id); err != nil {
+		return repository.UserWithToken{}, NewError("can't mark refresh token as used", err)
+	}
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return repository.UserWithToken{}, NewError("can't add refresh token to user", err)
+	}
+
+	uwt := repository.UserWithToken{ID: next.UserID}
+	if err := tx.QueryRow(ctx, `SELECT name, COALESCE(is_admin, false),
+	ARRAY(SELECT tag_id FROM users_tags WHERE user_id = users.id ORDER BY tag_id) FROM users WHERE id = $1`, next.UserID).
+		Scan(&uwt.Name, &uwt.IsAdmin, &uwt.Tags); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return repository.UserWithToken{}, NewError("can't find user of refresh token", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.UserWithToken{}, NewError("can't commit transaction", err)
+	}
+	return uwt, nil
+}
+
+func insertRefreshToken(ctx context.Context, tx pgx.Tx, token repository.RefreshToken) error {
+	_, err := tx.Exec(ctx, `INSERT INTO refresh_tokens (user_id, family, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		token.UserID, token.Family, token.Hash, token.ExpiresAt)
+	return err
+}
+
+func (s UserStorage) RevokeToken(ctx context.Context, token repository.RevokedToken) repository.DatabaseError {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < now()`); err != nil {
+		return NewError("can't delete expired revoked tokens", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		token.JTI, token.ExpiresAt); err != nil {
+		return NewError("can't revoke token", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tokens WHERE token = $1`, token.Token); err != nil {
+		return NewError("can't delete token", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
+	return nil
+}
+
+func (s UserStorage) RevokeRefreshToken(ctx context.Context, name, hash string) repository.DatabaseError {
+	if _, err := s.conn.PC.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now()
+	WHERE revoked_at IS NULL AND family = (
+		SELECT rt.family FROM refresh_tokens rt JOIN users u ON u.id = rt.user_id WHERE rt.token_hash = $1 AND u.name = $2
+	)`, hash, name); err != nil {
+		return NewError("can't revoke refresh token", err)
+	}
+	return nil
+}
+
+func (s UserStorage) RevokeUser(ctx context.Context, name string) (time.Time, repository.DatabaseError) {
+	errString := fmt.Sprintf("can't revoke tokens of user %s", name)
+
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return time.Time{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := lockUser(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+	revokedAt, err := revokeUserTokens(ctx, tx, id, name)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, NewError("can't commit transaction", err)
+	}
+	return revokedAt, nil
+}
+
+// revokeUserAccess rejects every access token issued to the user so far, the revocation is kept by name
+// and outlives the user. Refresh tokens stay valid, so clients get tokens with fresh claims.
+func revokeUserAccess(ctx context.Context, tx pgx.Tx, name string) (time.Time, error) {
+	var revokedAt time.Time
+	if err := tx.QueryRow(ctx, `INSERT INTO users_revocations (name) VALUES ($1)
+	ON CONFLICT (name) DO UPDATE SET revoked_at = now() RETURNING revoked_at`, name).Scan(&revokedAt); err != nil {
+		return time.Time{}, err
+	}
+	return revokedAt, nil
+}
+
+// revokeUserTokens rejects every access and refresh token issued to the locked user so far.
+func revokeUserTokens(ctx context.Context, tx pgx.Tx, id int, name string) (time.Time, error) {
+	revokedAt, err := revokeUserAccess(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, id); err != nil {
+		return time.Time{}, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tokens WHERE user_id = $1`, id); err != nil {
+		return time.Time{}, err
+	}
+	return revokedAt, nil
+}
+
+func (s UserStorage) Revocations(ctx context.Context, since time.Time) (repository.Revocations, repository.DatabaseError) {
+	revocations := repository.Revocations{
+		Tokens: make(map[string]time.Time),
+		Users:  make(map[string]time.Time),
+	}
+
+	rows, err := s.conn.PC.Query(ctx, `SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > now()`)
+	if err != nil {
+		return repository.Revocations{}, NewError("can't get revoked tokens", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			jti       string
+			expiresAt time.Time
+		)
+		if err := rows.Scan(&jti, &expiresAt); err != nil {
+			return repository.Revocations{}, NewError("can't scan revoked token from row", err)
+		}
+		revocations.Tokens[jti] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		return repository.Revocations{}, NewError("can't read revoked tokens", err)
+	}
+
+	rows, err = s.conn.PC.Query(ctx, `SELECT name, revoked_at FROM users_revocations WHERE revoked_at > $1`, since)
+	if err != nil {
+		return repository.Revocations{}, NewError("can't get revoked users", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name      string
+			revokedAt time.Time
+		)
+		if err := rows.Scan(&name, &revokedAt); err != nil {
+			return repository.Revocations{}, NewError("can't scan revoked user from row", err)
+		}
+		revocations.Users[name] = revokedAt
+	}
+	if err := rows.Err(); err != nil {
+		return repository.Revocations{}, NewError("can't read revoked users", err)
+	}
+	return revocations, nil
+}
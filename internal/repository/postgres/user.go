@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/antsrp/banner_service/internal/domain/models"
 	"github.com/antsrp/banner_service/internal/repository"
@@ -40,24 +41,23 @@ func (s UserStorage) Create(ctx context.Context, user models.User) repository.Da
 		}
 		return NewError(errString, err)
 	}
-	values := make([]string, 0, len(user.Tags))
-	for _, tag := range user.Tags {
-		values = append(values, fmt.Sprintf("(%d, %d)", id, tag))
-	}
-	if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO users_tags (user_id, tag_id) VALUES %s`, strings.Join(values, ","))); err != nil {
-		return NewError("can't add tags for user", err)
+	if err := insertUserTags(ctx, tx, id, user.Tags); err != nil {
+		return NewError("can't add tags for user", unknownTags(err))
 	}
 
-	tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
 	return nil
 }
 func (s UserStorage) FindByName(ctx context.Context, name string) (repository.UserWithToken, repository.DatabaseError) {
 	var uwt repository.UserWithToken
-	var token sql.NullString
-	query := `SELECT users.id, name, is_admin, token FROM users 
+	var token, passwordHash sql.NullString
+	query := `SELECT users.id, name, is_admin, token, password_hash,
+	ARRAY(SELECT tag_id FROM users_tags WHERE user_id = users.id ORDER BY tag_id) FROM users 
 	LEFT JOIN tokens ON users.id = tokens.user_id 
 	WHERE name = $1`
-	if err := s.conn.PC.QueryRow(ctx, query, name).Scan(&uwt.ID, &uwt.Name, &uwt.IsAdmin, &token); err != nil {
+	if err := s.conn.PC.QueryRow(ctx, query, name).Scan(&uwt.ID, &uwt.Name, &uwt.IsAdmin, &token, &passwordHash, &uwt.Tags); err != nil {
 		errString := "can't find user by name"
 		if errors.Is(err, pgx.ErrNoRows) {
 			err = repository.ErrEntityNotFound
@@ -67,6 +67,9 @@ func (s UserStorage) FindByName(ctx context.Context, name string) (repository.Us
 	if token.Valid {
 		uwt.Token = token.String
 	}
+	if passwordHash.Valid {
+		uwt.PasswordHash = passwordHash.String
+	}
 	return uwt, nil
 }
 func (s UserStorage) AddToken(ctx context.Context, uwt repository.UserWithToken) repository.DatabaseError {
@@ -78,4 +81,191 @@ func (s UserStorage) AddToken(ctx context.Context, uwt repository.UserWithToken)
 	return nil
 }
 
+func unknownTags(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		return repository.ErrUnknownTags
+	}
+	return err
+}
+
+func insertUserTags(ctx context.Context, tx pgx.Tx, userID int, tags []int) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		values = append(values, fmt.Sprintf("(%d, %d)", userID, tag))
+	}
+	_, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO users_tags (user_id, tag_id) VALUES %s ON CONFLICT DO NOTHING`, strings.Join(values, ",")))
+	return err
+}
+
+func lockUser(ctx context.Context, tx pgx.Tx, name string) (int, error) {
+	var id int
+	if err := tx.QueryRow(ctx, `SELECT id FROM users WHERE name = $1 FOR UPDATE`, name).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s UserStorage) Get(ctx context.Context, opts repository.GetUsersLimited) ([]models.User, repository.DatabaseError) {
+	var limitConditions []string
+	if opts.Limit > 0 {
+		limitConditions = append(limitConditions, fmt.Sprintf("LIMIT %d", opts.Limit))
+	}
+	if opts.Offset > 0 {
+		limitConditions = append(limitConditions, fmt.Sprintf("OFFSET %d", opts.Offset))
+	}
+	query := fmt.Sprintf(`SELECT u.name, COALESCE(u.is_admin, false), ARRAY(SELECT tag_id FROM users_tags WHERE user_id = u.id ORDER BY tag_id)
+	FROM users u
+	ORDER BY u.id
+	%s`, strings.Join(limitConditions, " "))
+
+	rows, err := s.conn.PC.Query(ctx, query)
+	if err != nil {
+		return nil, NewError("can't get users from database", err)
+	}
+	defer rows.Close()
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.Name, &user.IsAdmin, &user.Tags); err != nil {
+			return nil, NewError("can't scan user from row", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't read users", err)
+	}
+	return users, nil
+}
+
+func (s UserStorage) SetAdmin(ctx context.Context, name string, isAdmin bool) (time.Time, repository.DatabaseError) {
+	errString := fmt.Sprintf("can't update user %s", name)
+
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return time.Time{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := lockUser(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+	tag, err := tx.Exec(ctx, `UPDATE users SET is_admin = $2 WHERE id = $1 AND COALESCE(is_admin, false) <> $2`, id, isAdmin)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return time.Time{}, nil
+	}
+	// tokens carry is_admin, the ones issued before must not keep the old rights
+	revokedAt, err := revokeUserTokens(ctx, tx, id, name)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, NewError("can't commit transaction", err)
+	}
+	return revokedAt, nil
+}
+
+func (s UserStorage) SetPassword(ctx context.Context, name, hash string) repository.DatabaseError {
+	tag, err := s.conn.PC.Exec(ctx, `UPDATE users SET password_hash = $2 WHERE name = $1`, name, hash)
+	errString := fmt.Sprintf("can't set password of user %s", name)
+	if err != nil {
+		return NewError(errString, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(errString, repository.ErrEntityNotFound)
+	}
+	return nil
+}
+
+func (s UserStorage) AddTags(ctx context.Context, name string, tags []int) (time.Time, repository.DatabaseError) {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return time.Time{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := lockUser(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError("can't find user by name", err)
+	}
+	if err := insertUserTags(ctx, tx, id, tags); err != nil {
+		return time.Time{}, NewError("can't add tags for user", unknownTags(err))
+	}
+	revokedAt, err := revokeUserAccess(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError("can't revoke access tokens of user", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, NewError("can't commit transaction", err)
+	}
+	return revokedAt, nil
+}
+
+func (s UserStorage) RemoveTags(ctx context.Context, name string, tags []int) (time.Time, repository.DatabaseError) {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return time.Time{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := lockUser(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError("can't find user by name", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM users_tags WHERE user_id = $1 AND tag_id = ANY($2)`, id, tags); err != nil {
+		return time.Time{}, NewError("can't remove tags from user", err)
+	}
+	revokedAt, err := revokeUserAccess(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError("can't revoke access tokens of user", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, NewError("can't commit transaction", err)
+	}
+	return revokedAt, nil
+}
+
+func (s UserStorage) Delete(ctx context.Context, name string) (time.Time, repository.DatabaseError) {
+	errString := fmt.Sprintf("can't delete user %s", name)
+
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return time.Time{}, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := lockUser(ctx, tx, name)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+	revokedAt, err := revokeUserTokens(ctx, tx, id, name)
+	if err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM users_tags WHERE user_id = $1`, id); err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+		return time.Time{}, NewError(errString, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, NewError("can't commit transaction", err)
+	}
+	return revokedAt, nil
+}
+
 var _ repository.UserStorage = UserStorage{}
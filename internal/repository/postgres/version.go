@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	mapper "github.com/antsrp/banner_service/pkg/presenters"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// writeVersion snapshots the current state of the banner into banners_versions.
+// It must be called inside the transaction that changed the banner.
+func writeVersion(ctx context.Context, tx pgx.Tx, bannerID int, author string) error {
+	_, err := tx.Exec(ctx, `INSERT INTO banners_versions (banner_id, version, feature_id, tag_ids, content, is_active, active_from, active_until, author)
+	SELECT b.id,
+		COALESCE((SELECT MAX(version) FROM banners_versions WHERE banner_id = b.id), 0) + 1,
+		b.feature_id,
+		ARRAY(SELECT tag_id FROM banners_tags WHERE banner_id = b.id ORDER BY tag_id),
+		b.content, b.is_active, b.active_from, b.active_until, $2
+	FROM banners b WHERE b.id = $1`, bannerID, author)
+	return err
+}
+
+func scanVersion(row pgx.Row) (models.BannerVersion, error) {
+	version := models.BannerVersion{
+		Content: make(models.BannerContent),
+	}
+	var (
+		featureID               sql.NullInt64
+		isActive                sql.NullBool
+		activeFrom, activeUntil sql.NullTime
+		author                  sql.NullString
+	)
+	if err := row.Scan(&version.BannerID, &version.Version, &featureID, &version.TagIDS, &version.Content, &isActive, &activeFrom, &activeUntil, &author, &version.CreatedAt); err != nil {
+		return models.BannerVersion{}, err
+	}
+	if featureID.Valid {
+		version.FeatureID = int(featureID.Int64)
+	}
+	if isActive.Valid {
+		version.IsActive = isActive.Bool
+	}
+	if activeFrom.Valid {
+		version.ActiveFrom = &activeFrom.Time
+	}
+	if activeUntil.Valid {
+		version.ActiveUntil = &activeUntil.Time
+	}
+	if author.Valid {
+		version.Author = author.String
+	}
+	return version, nil
+}
+
+func bannerExists(ctx context.Context, tx pgx.Tx, id int) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM banners WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// versionRefsMissing reports a version pointing to a feature or tags deleted since it was written.
+func versionRefsMissing(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		return repository.ErrVersionRefsMissing
+	}
+	return err
+}
+
+// Versions returns the history of the banner, which is kept after the banner is deleted.
+func (s BannerStorage) Versions(ctx context.Context, bannerID int) ([]models.BannerVersion, repository.DatabaseError) {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return nil, NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT banner_id, version, feature_id, tag_ids, content, is_active, active_from, active_until, author, created_at
+	FROM banners_versions WHERE banner_id = $1 ORDER BY version DESC`, bannerID)
+	if err != nil {
+		return nil, NewError("can't get banner versions from database", err)
+	}
+	defer rows.Close()
+
+	versions := make([]models.BannerVersion, 0)
+	for rows.Next() {
+		version, err := scanVersion(rows)
+		if err != nil {
+			return nil, NewError("can't scan banner version from row", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't read banner versions", err)
+	}
+	if len(versions) == 0 {
+		// a banner created before versioning has no history yet
+		if ok, err := bannerExists(ctx, tx, bannerID); err != nil {
+			return nil, NewError("can't check banner existence", err)
+		} else if !ok {
+			return nil, NewError(fmt.Sprintf("can't get versions of banner with id %d", bannerID), repository.ErrEntityNotFound)
+		}
+	}
+
+	return versions, nil
+}
+
+func (s BannerStorage) Version(ctx context.Context, opts repository.GetBannerVersion) (models.BannerVersion, repository.DatabaseError) {
+	version, err := scanVersion(s.conn.PC.QueryRow(ctx, `SELECT banner_id, version, feature_id, tag_ids, content, is_active, active_from, active_until, author, created_at
+	FROM banners_versions WHERE banner_id = $1 AND version = $2`, opts.BannerID, opts.Version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return models.BannerVersion{}, NewError("can't scan banner version from row", err)
+	}
+	return version, nil
+}
+
+// Restore brings the banner back to the version. A deleted banner is created again under its old id.
+func (s BannerStorage) Restore(ctx context.Context, opts repository.GetBannerVersion, author string) repository.DatabaseError {
+	tx, err := s.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't create transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	version, err := scanVersion(tx.QueryRow(ctx, `SELECT banner_id, version, feature_id, tag_ids, content, is_active, active_from, active_until, author, created_at
+	FROM banners_versions WHERE banner_id = $1 AND version = $2`, opts.BannerID, opts.Version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repository.ErrEntityNotFound
+		}
+		return NewError("can't find banner version to restore", err)
+	}
+
+	deleted := false
+	if _, _, err := bannerState(ctx, tx, opts.BannerID); errors.Is(err, repository.ErrEntityNotFound) {
+		deleted = true
+	} else if err != nil {
+		return NewError("can't restore banner", err)
+	}
+	if err := checkConflicts(ctx, tx, opts.BannerID, version.FeatureID, version.TagIDS); err != nil {
+		return NewError("can't restore banner", err)
+	}
+
+	contentData, err := mapper.ToJSON(version.Content, &mapper.DefaultIndent)
+	if err != nil {
+		return NewError("can't present banner's content to json", err)
+	}
+	if deleted {
+		// the id was issued by the sequence before, so reusing it can't collide with a new banner
+		if _, err := tx.Exec(ctx, `INSERT INTO banners (id, feature_id, is_active, content, active_from, active_until) VALUES ($1, $2, $3, $4, $5, $6)`,
+			opts.BannerID, version.FeatureID, version.IsActive, contentData, version.ActiveFrom, version.ActiveUntil); err != nil {
+			return NewError("can't restore banner", versionRefsMissing(err))
+		}
+	} else {
+		if _, err := tx.Exec(ctx, `DELETE FROM banners_tags WHERE banner_id = $1`, opts.BannerID); err != nil {
+			return NewError("can't update tags for banner", err)
+		}
+		tag, err := tx.Exec(ctx, `UPDATE banners SET feature_id = $2, is_active = $3, content = $4, active_from = $5, active_until = $6, updated_at = now() WHERE id = $1`,
+			opts.BannerID, version.FeatureID, version.IsActive, contentData, version.ActiveFrom, version.ActiveUntil)
+		if err != nil {
+			return NewError("can't restore banner", versionRefsMissing(s.conflictFromViolation(ctx, err, opts.BannerID, version.FeatureID, version.TagIDS)))
+		}
+		if tag.RowsAffected() == 0 {
+			return NewError("can't restore banner", repository.ErrEntityNotFound)
+		}
+	}
+	if err := insertTags(ctx, tx, opts.BannerID, version.FeatureID, version.TagIDS); err != nil {
+		return NewError("can't add tags for banner", versionRefsMissing(s.conflictFromViolation(ctx, err, opts.BannerID, version.FeatureID, version.TagIDS)))
+	}
+	if err := writeVersion(ctx, tx, opts.BannerID, author); err != nil {
+		return NewError("can't write banner version", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+)
+
+type GetTagsLimited struct {
+	Limit  int
+	Offset int
+}
+
+type TagStorage interface {
+	Create(context.Context, models.Tag) (models.Tag, DatabaseError)
+	Get(ctx context.Context, opts GetTagsLimited) ([]models.Tag, DatabaseError)
+	GetOne(ctx context.Context, id int) (models.Tag, DatabaseError)
+	Rename(ctx context.Context, id int, name string) DatabaseError
+	// Delete returns the (feature, tag) pairs that lost their banner in the cascade
+	Delete(ctx context.Context, id int, cascade bool, author string) ([]models.BannerKey, DatabaseError)
+}
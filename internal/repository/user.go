@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/antsrp/banner_service/internal/domain/models"
 )
@@ -10,10 +11,60 @@ type UserWithToken struct {
 	ID int
 	models.User
 	Token string
+	// PasswordHash is empty when no password is set
+	PasswordHash string
+}
+
+// RefreshToken is stored by the hash only, the token itself is known to the client alone.
+type RefreshToken struct {
+	UserID    int
+	Family    string
+	Hash      string
+	ExpiresAt time.Time
+}
+
+// RevokedToken is an access token rejected until it expires on its own.
+type RevokedToken struct {
+	JTI       string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Revocations holds the expiry of every revoked token by its id
+// and the time before which all tokens of a user are rejected.
+type Revocations struct {
+	Tokens map[string]time.Time
+	Users  map[string]time.Time
+}
+
+type GetUsersLimited struct {
+	Limit  int
+	Offset int
 }
 
 type UserStorage interface {
 	Create(context.Context, models.User) DatabaseError
 	FindByName(context.Context, string) (UserWithToken, DatabaseError)
 	AddToken(context.Context, UserWithToken) DatabaseError
+	AddRefreshToken(context.Context, RefreshToken) DatabaseError
+	// RotateRefreshToken marks the token with the hash as used and stores next in the same family.
+	// Presenting an already used token revokes the whole family.
+	RotateRefreshToken(ctx context.Context, hash string, next RefreshToken) (UserWithToken, DatabaseError)
+	RevokeToken(context.Context, RevokedToken) DatabaseError
+	// RevokeRefreshToken revokes the family of the user's refresh token with the hash.
+	RevokeRefreshToken(ctx context.Context, name, hash string) DatabaseError
+	// RevokeUser rejects every token issued to the user so far and returns the cutoff time.
+	RevokeUser(ctx context.Context, name string) (time.Time, DatabaseError)
+	// Revocations returns tokens that haven't expired yet and users revoked after since.
+	Revocations(ctx context.Context, since time.Time) (Revocations, DatabaseError)
+
+	Get(ctx context.Context, opts GetUsersLimited) ([]models.User, DatabaseError)
+	// SetAdmin revokes tokens of the user when the flag changes and returns the cutoff time, zero if it didn't change.
+	SetAdmin(ctx context.Context, name string, isAdmin bool) (time.Time, DatabaseError)
+	SetPassword(ctx context.Context, name, hash string) DatabaseError
+	// AddTags and RemoveTags revoke access tokens of the user, since they carry the tags, and return the cutoff time
+	AddTags(ctx context.Context, name string, tags []int) (time.Time, DatabaseError)
+	RemoveTags(ctx context.Context, name string, tags []int) (time.Time, DatabaseError)
+	// Delete revokes tokens of the user as well and returns the cutoff time.
+	Delete(ctx context.Context, name string) (time.Time, DatabaseError)
 }
@@ -1,7 +1,9 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -26,25 +28,33 @@ func newAuthHandler(us service.UserStorager, logger logger.Logger) authHandler {
 	}
 }
 
-func (h authHandler) parseToken(ctx *gin.Context) (models.User, error) {
+func bearerToken(ctx *gin.Context) (string, error) {
 	header := ctx.GetHeader("Authorization")
 	if header == "" {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return models.User{}, fmt.Errorf("no token provided")
+		return "", fmt.Errorf("no token provided")
 	}
 
 	parts := strings.Split(header, " ")
 	if len(parts) != 2 {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return models.User{}, fmt.Errorf("bad token provided")
+		return "", fmt.Errorf("bad token provided")
 	}
 
 	if parts[0] != "Bearer" {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return models.User{}, fmt.Errorf("not a bearer token")
+		return "", fmt.Errorf("not a bearer token")
+	}
+	return parts[1], nil
+}
+
+func (h authHandler) parseToken(ctx *gin.Context) (models.User, error) {
+	token, terr := bearerToken(ctx)
+	if terr != nil {
+		return models.User{}, terr
 	}
 
-	user, err := h.storage.UserByToken(parts[1])
+	user, err := h.storage.UserByToken(token)
 	if err != nil {
 		//h.logger.Error("error while parsing token: %v", err.Cause().Error())
 		if err.IsInternal() {
@@ -86,15 +96,158 @@ func (h authHandler) signIn(c *gin.Context) {
 		return
 	}
 
-	token, err := h.storage.SignIn(input.Name)
+	if input.Name == "" || input.Password == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "fields name and password are required"})
+		return
+	}
+
+	tokens, err := h.storage.SignIn(input)
+	if err != nil {
+		if err.IsInternal() {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		} else {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": service.ErrInvalidCredentials.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+/*
+summary: Публичные ключи для проверки токенов в формате JWKS
+
+	responses:
+	  '200':
+	    description: OK, для подписи с общим секретом список ключей пуст
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            keys:
+	              type: array
+	              items:
+	                type: object
+	                description: Ключ в формате RFC 7517, kid совпадает с заголовком kid токена
+*/
+func (h authHandler) jwks(c *gin.Context) { // GET /.well-known/jwks.json
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.storage.JWKS())
+}
+
+/*
+summary: Выход, отзыв текущего токена доступа
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен пользователя
+	    schema:
+	      type: string
+	      example: "user_token"
+	requestBody:
+	  required: false
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          refresh_token:
+	            type: string
+	            description: Refresh токен того же входа, будет отозван вместе со всеми выданными взамен него
+	responses:
+	  '204':
+	    description: Токены отозваны
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h authHandler) signOut(c *gin.Context) { // POST /signout
+	var req requests.SignOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	token, terr := bearerToken(c)
+	if terr != nil {
+		return
+	}
+	req.Token = token
+
+	if err := h.storage.SignOut(req); err != nil {
+		if err.IsInternal() {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		} else {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+/*
+summary: Обмен refresh токена на новую пару токенов
+
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          refresh_token:
+	            type: string
+	            description: Refresh токен, полученный при входе или предыдущем обмене
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            token:
+	              type: string
+	              description: Новый токен доступа
+	            refresh_token:
+	              type: string
+	              description: Новый refresh токен, старый больше не действует
+	            expires_at:
+	              type: string
+	              format: date-time
+	              description: Время истечения токена доступа
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Refresh токен недействителен, истёк или уже был использован
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h authHandler) refreshToken(c *gin.Context) { // POST /token/refresh
+	var input requests.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.RefreshToken == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field refresh_token is empty"})
+		return
+	}
+
+	tokens, err := h.storage.Refresh(input.RefreshToken)
 	if err != nil {
-		status := http.StatusBadRequest
 		if err.IsInternal() {
-			status = http.StatusInternalServerError
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		} else {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Cause().Error()})
 		}
-		c.AbortWithStatusJSON(status, gin.H{"error": err.Cause().Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, tokens)
 }
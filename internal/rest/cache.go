@@ -0,0 +1,253 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+/*
+summary: Запуск обновления кэша
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: full
+	    required: false
+	    schema:
+	      type: boolean
+	      default: false
+	      description: Перезагрузить кэш целиком, а не только изменившиеся баннеры
+	responses:
+	  '202':
+	    description: Обновление запланировано, результат виден в /admin/cache/status
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+*/
+func (h Handler) refreshCache(c *gin.Context) { // POST /admin/cache/refresh
+	var full bool
+	if value, ok := c.GetQuery("full"); ok {
+		if val, err := strconv.ParseBool(value); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "full parameter is not a boolean type"})
+			return
+		} else {
+			full = val
+		}
+	}
+
+	h.transmitter.Refresh(full)
+
+	c.Status(http.StatusAccepted)
+}
+
+/*
+summary: Состояние обновления кэша
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            last_refresh:
+	              type: object
+	              nullable: true
+	              description: Последнее обновление, успешное или нет
+	              properties:
+	                started_at:
+	                  type: string
+	                  format: date-time
+	                duration_ms:
+	                  type: integer
+	                full:
+	                  type: boolean
+	                keys_written:
+	                  type: integer
+	                keys_deleted:
+	                  type: integer
+	                error:
+	                  type: string
+	            last_success_at:
+	              type: string
+	              format: date-time
+	              nullable: true
+	            last_error:
+	              type: string
+	            last_error_at:
+	              type: string
+	              format: date-time
+	            interval:
+	              type: string
+	              description: Интервал между обновлениями
+	            jitter:
+	              type: string
+	              description: Максимальная случайная добавка к интервалу
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+*/
+func (h Handler) cacheStatus(c *gin.Context) { // GET /admin/cache/status
+	c.JSON(http.StatusOK, h.transmitter.Status())
+}
+
+func (h Handler) bindCachedBanner(c *gin.Context) (requests.CachedBannerRequest, bool) {
+	var req requests.CachedBannerRequest
+
+	if val, err := strconv.Atoi(c.Query("feature_id")); err != nil || val <= 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "feature id is not set or is not a positive integer"})
+		return req, false
+	} else {
+		req.FeatureID = val
+	}
+	if val, err := strconv.Atoi(c.Query("tag_id")); err != nil || val <= 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "tag id is not set or is not a positive integer"})
+		return req, false
+	} else {
+		req.TagID = val
+	}
+	return req, true
+}
+
+/*
+summary: Сравнение баннера в кэше с баннером в базе
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: feature_id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: query
+	    name: tag_id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор тега
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            key:
+	              type: string
+	              description: Ключ в кэше
+	            cached:
+	              type: object
+	              nullable: true
+	              description: Баннер из кэша
+	            ttl_seconds:
+	              type: integer
+	              nullable: true
+	              description: Сколько секунд осталось жить записи, 0 - бессрочно
+	            database:
+	              type: object
+	              nullable: true
+	              description: Баннер, который сейчас отдаёт база
+	            differ:
+	              type: boolean
+	              description: Кэш и база расходятся
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) cachedBanner(c *gin.Context) { // GET /admin/cache/banner
+	req, ok := h.bindCachedBanner(c)
+	if !ok {
+		return
+	}
+
+	cached, err := h.bannerService.Cached(req)
+	if err != nil {
+		h.logger.Error("can't inspect cached banner: %v", err.Cause().Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cached)
+}
+
+/*
+summary: Удаление баннера из кэша
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: feature_id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: query
+	    name: tag_id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор тега
+	responses:
+	  '204':
+	    description: Запись удалена из кэша или её там не было
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) evictCachedBanner(c *gin.Context) { // DELETE /admin/cache/banner
+	req, ok := h.bindCachedBanner(c)
+	if !ok {
+		return
+	}
+
+	if err := h.bannerService.Evict(req); err != nil {
+		h.logger.Error("can't evict cached banner: %v", err.Cause().Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,457 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	"github.com/antsrp/banner_service/pkg/jsonschema"
+	"github.com/gin-gonic/gin"
+)
+
+func (h Handler) abortFeatureError(c *gin.Context, err service.Error) {
+	switch {
+	case errors.Is(err.Cause(), service.ErrFeatureNotFound):
+		c.AbortWithStatus(http.StatusNotFound)
+	case errors.Is(err.Cause(), service.ErrFeatureAlreadyExists), errors.Is(err.Cause(), service.ErrFeatureHasBanners):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Cause().Error()})
+	case errors.Is(err.Cause(), service.ErrInvalidSchema), errors.Is(err.Cause(), service.ErrInvalidContent):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Cause().Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+	}
+}
+
+/*
+summary: Получение списка фич
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: limit
+	    required: false
+	    schema:
+	      type: integer
+	      description: Лимит
+	  - in: query
+	    name: offset
+	    required: false
+	    schema:
+	      type: integer
+	      description: Оффсет
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: array
+	          items:
+	            type: object
+	            properties:
+	              id:
+	                type: integer
+	                description: Идентификатор фичи
+	              name:
+	                type: string
+	                description: Название фичи
+	              description:
+	                type: string
+	                description: Описание фичи
+	              content_schema:
+	                type: object
+	                description: JSON Schema содержимого баннеров фичи
+	              banners_count:
+	                type: integer
+	                description: Количество баннеров фичи
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) getFeatures(c *gin.Context) { // GET /feature
+	var req requests.GetFeaturesRequest
+
+	if limit, ok := c.GetQuery("limit"); ok {
+		if val, err := strconv.Atoi(limit); err != nil {
+			h.logger.Info("can't parse limit: %v", err.Error())
+		} else {
+			req.Limit = val
+		}
+	}
+	if offset, ok := c.GetQuery("offset"); ok {
+		if val, err := strconv.Atoi(offset); err != nil {
+			h.logger.Info("can't parse offset: %v", err.Error())
+		} else {
+			req.Offset = val
+		}
+	}
+
+	features, err := h.featureService.Get(req)
+	if err != nil {
+		h.logger.Error("can't get features: %v", err.Cause().Error())
+		h.abortFeatureError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, features)
+}
+
+/*
+summary: Получение фичи по идентификатору
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Фича не найдена
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) getFeature(c *gin.Context) { // GET /feature/{id}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	}
+
+	feature, serr := h.featureService.GetOne(requests.GetFeatureRequest{ID: id})
+	if serr != nil {
+		h.logger.Error("can't get feature: %v", serr.Cause().Error())
+		h.abortFeatureError(c, serr)
+		return
+	}
+
+	c.JSON(http.StatusOK, feature)
+}
+
+/*
+summary: Создание новой фичи
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          name:
+	            type: string
+	            description: Название фичи
+	          description:
+	            type: string
+	            description: Описание фичи
+	          content_schema:
+	            type: object
+	            description: JSON Schema содержимого баннеров фичи
+	responses:
+	  '201':
+	    description: Created
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            feature_id:
+	              type: integer
+	              description: Идентификатор созданной фичи
+	  '400':
+	    description: Некорректные данные или некорректная схема
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '409':
+	    description: Фича с таким названием уже существует
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) addFeature(c *gin.Context) { // POST /feature
+	var req requests.CreateFeatureRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field name is empty"})
+		return
+	}
+
+	feature, err := h.featureService.Create(req)
+	if err != nil {
+		h.logger.Error("can't create feature: %v", err.Cause().Error())
+		h.abortFeatureError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, requests.CreateFeatureResponse{FeatureID: feature.ID})
+}
+
+/*
+summary: Переименование фичи, изменение её описания и схемы содержимого
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          name:
+	            nullable: true
+	            type: string
+	            description: Название фичи
+	          description:
+	            nullable: true
+	            type: string
+	            description: Описание фичи
+	          content_schema:
+	            nullable: true
+	            type: object
+	            description: JSON Schema содержимого баннеров фичи, null удаляет схему
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные или некорректная схема
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Фича не найдена
+	  '409':
+	    description: Фича с таким названием уже существует
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) updateFeature(c *gin.Context) { // PATCH /feature/{id}
+	var req requests.UpdateFeatureRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if id, err := strconv.Atoi(c.Param("id")); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	} else {
+		req.ID = id
+	}
+	if req.Name != nil && *req.Name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field name is empty"})
+		return
+	}
+
+	if err := h.featureService.Update(req); err != nil {
+		h.logger.Error("can't update feature: %v", err.Cause().Error())
+		h.abortFeatureError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/*
+summary: Удаление фичи по идентификатору
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: query
+	    name: cascade
+	    required: false
+	    schema:
+	      type: boolean
+	      default: false
+	      description: Удалить вместе с баннерами фичи
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '204':
+	    description: Фича успешно удалена
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Фича не найдена
+	  '409':
+	    description: У фичи есть баннеры, а cascade не указан
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) deleteFeature(c *gin.Context) { // DELETE /feature/{id}
+	var req requests.DeleteFeatureRequest
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	}
+	req.ID = id
+	if cascade, ok := c.GetQuery("cascade"); ok {
+		if val, err := strconv.ParseBool(cascade); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "cascade parameter is not a boolean type"})
+			return
+		} else {
+			req.Cascade = val
+		}
+	}
+
+	if err := h.featureService.Delete(req); err != nil {
+		h.logger.Error("can't delete feature: %v", err.Cause().Error())
+		h.abortFeatureError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+/*
+summary: Проверка содержимого баннера по схеме фичи
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          content:
+	            type: object
+	            description: Содержимое баннера
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            valid:
+	              type: boolean
+	              description: Содержимое соответствует схеме
+	            violations:
+	              type: array
+	              items:
+	                type: object
+	                properties:
+	                  pointer:
+	                    type: string
+	                    description: JSON Pointer на неверное значение
+	                  message:
+	                    type: string
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Фича не найдена
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) validateFeatureContent(c *gin.Context) { // POST /feature/{id}/validate
+	var req requests.ValidateContentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if id, err := strconv.Atoi(c.Param("id")); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	} else {
+		req.FeatureID = id
+	}
+	if req.Content == nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field content is empty"})
+		return
+	}
+
+	violations, err := h.featureService.ValidateContent(req)
+	if err != nil {
+		h.logger.Error("can't validate content: %v", err.Cause().Error())
+		h.abortFeatureError(c, err)
+		return
+	}
+	if violations == nil {
+		violations = []jsonschema.Violation{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": len(violations) == 0, "violations": violations})
+}
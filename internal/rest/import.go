@@ -0,0 +1,223 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	mapper "github.com/antsrp/banner_service/pkg/presenters"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	maxImportLineSize = 1 << 20
+)
+
+/*
+summary: Выгрузка баннеров в формате NDJSON
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: feature_id
+	    required: false
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: query
+	    name: tag_id
+	    required: false
+	    schema:
+	      type: integer
+	      description: Идентификатор тега
+	responses:
+	  '200':
+	    description: По одному баннеру в формате JSON на строку
+	    content:
+	      application/x-ndjson: {}
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) exportBanners(c *gin.Context) { // GET /banner/export
+	var req requests.ExportBannersRequest
+
+	if tagId, ok := c.GetQuery("tag_id"); ok {
+		if val, err := strconv.Atoi(tagId); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "tag id is not an integer type"})
+			return
+		} else {
+			req.TagID = val
+		}
+	}
+	if featureId, ok := c.GetQuery("feature_id"); ok {
+		if val, err := strconv.Atoi(featureId); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "feature id is not an integer type"})
+			return
+		} else {
+			req.FeatureID = val
+		}
+	}
+
+	c.Header("Content-Type", ndjsonContentType)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	if err := h.bannerService.Export(req, func(banner models.Banner) error {
+		if err := encoder.Encode(banner); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}); err != nil {
+		h.logger.Error("can't export banners: %v", err.Cause().Error())
+		if !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		} else {
+			c.Abort()
+		}
+	}
+}
+
+/*
+summary: Загрузка баннеров в формате NDJSON
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: mode
+	    required: false
+	    schema:
+	      type: string
+	      enum: [upsert, skip_existing]
+	      default: skip_existing
+	      description: Что делать с баннером, если для его фичи и тэгов баннер уже существует. При upsert строка должна содержать все тэги найденного баннера, иначе она считается ошибочной
+	  - in: query
+	    name: dry_run
+	    required: false
+	    schema:
+	      type: boolean
+	      default: false
+	      description: Только проверить данные, ничего не сохраняя
+	requestBody:
+	  required: true
+	  content:
+	    application/x-ndjson:
+	      schema:
+	        description: По одному баннеру в формате выгрузки на строку
+	responses:
+	  '200':
+	    description: Все строки обработаны, изменения сохранены или проверены
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            mode:
+	              type: string
+	            dry_run:
+	              type: boolean
+	            applied:
+	              type: boolean
+	            created:
+	              type: integer
+	            updated:
+	              type: integer
+	            skipped:
+	              type: integer
+	            errors:
+	              type: array
+	              items:
+	                type: object
+	                properties:
+	                  line:
+	                    type: integer
+	                  error:
+	                    type: string
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '422':
+	    description: В некоторых строках ошибки, ничего не сохранено
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) importBanners(c *gin.Context) { // POST /banner/import
+	req := requests.ImportBannersRequest{Mode: models.ImportModeSkipExisting}
+
+	if mode, ok := c.GetQuery("mode"); ok {
+		switch models.ImportMode(mode) {
+		case models.ImportModeUpsert, models.ImportModeSkipExisting:
+			req.Mode = models.ImportMode(mode)
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "mode must be one of upsert, skip_existing"})
+			return
+		}
+	}
+	if dryRun, ok := c.GetQuery("dry_run"); ok {
+		if val, err := strconv.ParseBool(dryRun); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "dry_run parameter is not a boolean type"})
+			return
+		} else {
+			req.DryRun = val
+		}
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		banner, err := mapper.FromJSON[models.Banner](data)
+		if err != nil {
+			req.Errors = append(req.Errors, models.ImportLineError{Line: line, Error: err.Error()})
+			continue
+		}
+		req.Banners = append(req.Banners, requests.ImportBannerLine{Line: line, Banner: banner.BannerCommon})
+	}
+	if err := scanner.Err(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "can't read request body: " + err.Error()})
+		return
+	}
+
+	data, _ := c.Get(authusertag)
+	user := data.(models.User)
+
+	report, err := h.bannerService.Import(req, user.Name)
+	if err != nil {
+		h.logger.Error("can't import banners: %v", err.Cause().Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if len(report.Errors) != 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+/*
+summary: Удаление всех баннеров фичи и/или тэга в фоне
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: feature_id
+	    required: false
+	    schema:
+	      type: integer
+	      description: Идентификатор фичи
+	  - in: query
+	    name: tag_id
+	    required: false
+	    schema:
+	      type: integer
+	      description: Идентификатор тега
+	responses:
+	  '202':
+	    description: Удаление запущено, состояние задачи знает только принявший запрос экземпляр сервиса
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            job_id:
+	              type: string
+	              description: Идентификатор задачи
+	  '400':
+	    description: Некорректные данные или не указаны ни фича, ни тэг
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) deleteBanners(c *gin.Context) { // DELETE /banner
+	var req requests.DeleteBannersRequest
+
+	if tagId, ok := c.GetQuery("tag_id"); ok {
+		if val, err := strconv.Atoi(tagId); err != nil || val <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "tag id is not a positive integer"})
+			return
+		} else {
+			req.TagID = val
+		}
+	}
+	if featureId, ok := c.GetQuery("feature_id"); ok {
+		if val, err := strconv.Atoi(featureId); err != nil || val <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "feature id is not a positive integer"})
+			return
+		} else {
+			req.FeatureID = val
+		}
+	}
+	if req.FeatureID == 0 && req.TagID == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "feature_id or tag_id must be set"})
+		return
+	}
+
+	id, err := h.bannerService.DeleteMany(req)
+	if err != nil {
+		h.logger.Error("can't start deleting banners: %v", err.Cause().Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, requests.JobResponse{JobID: id})
+}
+
+/*
+summary: Получение состояния фоновой задачи. Задачи хранятся в памяти экземпляра, который их запустил:
+
+	  за несколькими экземплярами запрос должен попасть на тот же экземпляр, после перезапуска задача не найдется
+
+		parameters:
+		  - in: path
+		    name: id
+		    required: true
+		    schema:
+		      type: string
+		      description: Идентификатор задачи
+		  - in: header
+		    name: token
+		    description: Токен админа
+		    schema:
+		      type: string
+		      example: "admin_token"
+		responses:
+		  '200':
+		    description: OK
+		    content:
+		      application/json:
+		        schema:
+		          type: object
+		          properties:
+		            id:
+		              type: string
+		            kind:
+		              type: string
+		            status:
+		              type: string
+		              enum: [running, done, failed]
+		            total:
+		              type: integer
+		              description: Сколько объектов нужно обработать
+		            processed:
+		              type: integer
+		              description: Сколько объектов уже обработано
+		            error:
+		              type: string
+		            created_at:
+		              type: string
+		              format: date-time
+		            finished_at:
+		              type: string
+		              format: date-time
+		  '401':
+		    description: Пользователь не авторизован
+		  '403':
+		    description: Пользователь не имеет доступа
+		  '404':
+		    description: Задача не найдена, запущена другим экземпляром или до перезапуска
+		  '500':
+		    description: Внутренняя ошибка сервера
+*/
+func (h Handler) getJob(c *gin.Context) { // GET /jobs/{id}
+	job, err := h.jobService.Get(requests.GetJobRequest{ID: c.Param("id")})
+	if err != nil {
+		if errors.Is(err.Cause(), service.ErrJobNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		h.logger.Error("can't get job: %v", err.Cause().Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,23 +13,34 @@ import (
 	rs "github.com/antsrp/banner_service/pkg/infrastructure/rest"
 	"github.com/antsrp/banner_service/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type Handler struct {
-	engine        *gin.Engine
-	settings      rs.Settings
-	logger        logger.Logger
-	bannerService service.BannerServicer
-	auth          authHandler
+	engine         *gin.Engine
+	settings       rs.Settings
+	logger         logger.Logger
+	bannerService  service.BannerServicer
+	featureService service.FeatureServicer
+	tagService     service.TagServicer
+	userService    service.UserServicer
+	jobService     service.JobServicer
+	transmitter    service.Transmitter
+	auth           authHandler
 }
 
-func NewHandler(settings rs.Settings, logger logger.Logger, bs service.BannerServicer, us service.UserStorager) Handler {
+func NewHandler(settings rs.Settings, logger logger.Logger, bs service.BannerServicer, fs service.FeatureServicer, ts service.TagServicer, us service.UserServicer, js service.JobServicer, tr service.Transmitter) Handler {
 	h := Handler{
-		engine:        gin.Default(),
-		settings:      settings,
-		logger:        logger,
-		auth:          newAuthHandler(us, logger),
-		bannerService: bs,
+		engine:         gin.Default(),
+		settings:       settings,
+		logger:         logger,
+		auth:           newAuthHandler(us, logger),
+		bannerService:  bs,
+		featureService: fs,
+		tagService:     ts,
+		userService:    us,
+		jobService:     js,
+		transmitter:    tr,
 	}
 	h.routes()
 	return h
@@ -39,10 +51,77 @@ func (h Handler) routes() {
 	group.GET("/user_banner", h.auth.authRequired, h.userBanner)
 	group.GET("/banner", h.auth.adminAuthRequired, h.getBanner)
 	group.POST("/banner", h.auth.adminAuthRequired, h.addBanner)
+	group.DELETE("/banner", h.auth.adminAuthRequired, h.deleteBanners)
+	group.GET("/banner/export", h.auth.adminAuthRequired, h.exportBanners)
+	group.POST("/banner/import", h.auth.adminAuthRequired, h.importBanners)
 	group.PATCH("/banner/:id", h.auth.adminAuthRequired, h.updateBanner)
 	group.DELETE("/banner/:id", h.auth.adminAuthRequired, h.deleteBanner)
+	group.GET("/banner/:id/versions", h.auth.adminAuthRequired, h.bannerVersions)
+	group.GET("/banner/:id/versions/:n", h.auth.adminAuthRequired, h.bannerVersion)
+	group.POST("/banner/:id/versions/:n/restore", h.auth.adminAuthRequired, h.restoreBannerVersion)
+
+	group.GET("/feature", h.auth.adminAuthRequired, h.getFeatures)
+	group.POST("/feature", h.auth.adminAuthRequired, h.addFeature)
+	group.GET("/feature/:id", h.auth.adminAuthRequired, h.getFeature)
+	group.PATCH("/feature/:id", h.auth.adminAuthRequired, h.updateFeature)
+	group.DELETE("/feature/:id", h.auth.adminAuthRequired, h.deleteFeature)
+	group.POST("/feature/:id/validate", h.auth.adminAuthRequired, h.validateFeatureContent)
+
+	group.GET("/tag", h.auth.adminAuthRequired, h.getTags)
+	group.POST("/tag", h.auth.adminAuthRequired, h.addTag)
+	group.GET("/tag/:id", h.auth.adminAuthRequired, h.getTag)
+	group.PATCH("/tag/:id", h.auth.adminAuthRequired, h.updateTag)
+	group.DELETE("/tag/:id", h.auth.adminAuthRequired, h.deleteTag)
+
+	group.GET("/user", h.auth.adminAuthRequired, h.getUsers)
+	group.POST("/user", h.auth.adminAuthRequired, h.addUser)
+	group.PATCH("/user/:name", h.auth.adminAuthRequired, h.updateUser)
+	group.DELETE("/user/:name", h.auth.adminAuthRequired, h.deleteUser)
+	group.POST("/user/:name/tags", h.auth.adminAuthRequired, h.addUserTags)
+	group.DELETE("/user/:name/tags", h.auth.adminAuthRequired, h.removeUserTags)
+	group.POST("/user/:name/revoke", h.auth.adminAuthRequired, h.revokeUser)
+	group.PUT("/user/:name/password", h.auth.authRequired, h.setUserPassword)
+
+	group.GET("/jobs/:id", h.auth.adminAuthRequired, h.getJob)
+
+	group.POST("/admin/cache/refresh", h.auth.adminAuthRequired, h.refreshCache)
+	group.GET("/admin/cache/status", h.auth.adminAuthRequired, h.cacheStatus)
+	group.GET("/admin/cache/banner", h.auth.adminAuthRequired, h.cachedBanner)
+	group.DELETE("/admin/cache/banner", h.auth.adminAuthRequired, h.evictCachedBanner)
 
 	group.POST("/signin", h.auth.signIn)
+	group.POST("/token/refresh", h.auth.refreshToken)
+	group.POST("/signout", h.auth.authRequired, h.auth.signOut)
+	group.GET("/.well-known/jwks.json", h.auth.jwks)
+}
+
+func validWindow(banner models.BannerCommon) bool {
+	return banner.ActiveFrom == nil || banner.ActiveUntil == nil || banner.ActiveFrom.Before(*banner.ActiveUntil)
+}
+
+// abortOnConflict answers 409 with the conflicting banners when err is a service.BannerConflictError.
+func abortOnConflict(c *gin.Context, err service.Error) bool {
+	var conflict service.BannerConflictError
+	if !errors.As(err.Cause(), &conflict) {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": conflict.Error(), "conflicts": conflict.Conflicts})
+	return true
+}
+
+// abortOnInvalidContent answers 400 when the banner content doesn't match the schema of its feature
+// or the feature doesn't exist at all.
+func abortOnInvalidContent(c *gin.Context, err service.Error) bool {
+	var content service.ContentError
+	switch {
+	case errors.As(err.Cause(), &content):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": content.Error(), "violations": content.Violations})
+	case errors.Is(err.Cause(), service.ErrInvalidContent), errors.Is(err.Cause(), service.ErrFeatureNotFound):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Cause().Error()})
+	default:
+		return false
+	}
+	return true
 }
 
 func (h Handler) Run() error {
@@ -150,11 +229,13 @@ func (h Handler) userBanner(c *gin.Context) { // GET /user_banner
 	data, _ := c.Get(authusertag)
 	user := data.(models.User)
 
-	banner, err := h.bannerService.GetOne(req, user.Name)
+	banner, err := h.bannerService.GetOne(c.Request.Context(), req, user)
 	if err != nil {
 		h.logger.Error("can't get banner: %v", err.Cause().Error())
 		if errors.Is(err.Cause(), service.ErrBannerNotFound) {
 			c.AbortWithStatus(http.StatusNotFound)
+		} else if errors.Is(err.Cause(), service.ErrTagForbidden) {
+			c.AbortWithStatus(http.StatusForbidden)
 		} else {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
 		}
@@ -201,6 +282,13 @@ summary: Получение всех баннеров c фильтрацией 
 	    schema:
 	      type: integer
 	      description: Оффсет
+	  - in: query
+	    name: status
+	    required: false
+	    schema:
+	      type: string
+	      enum: [scheduled, live, expired]
+	      description: Состояние окна активности баннера
 	responses:
 	  '200':
 	    description: OK
@@ -283,6 +371,15 @@ func (h Handler) getBanner(c *gin.Context) { // GET /banner
 			req.Offset = val
 		}
 	}
+	if status, ok := c.GetQuery("status"); ok {
+		switch models.BannerStatus(status) {
+		case models.BannerStatusScheduled, models.BannerStatusLive, models.BannerStatusExpired:
+			req.Status = models.BannerStatus(status)
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "status must be one of scheduled, live, expired"})
+			return
+		}
+	}
 	banners, err := h.bannerService.Get(req)
 	if err != nil {
 		h.logger.Error("can't get banners: %v", err.Cause().Error())
@@ -326,6 +423,14 @@ summary: Создание нового баннера
 	          is_active:
 	            type: boolean
 	            description: Флаг активности баннера
+	          active_from:
+	            type: string
+	            format: date-time
+	            description: Начало показа баннера
+	          active_until:
+	            type: string
+	            format: date-time
+	            description: Окончание показа баннера
 	responses:
 	  '201':
 	    description: Created
@@ -338,7 +443,7 @@ summary: Создание нового баннера
 	              type: integer
 	              description: Идентификатор созданного баннера
 	  '400':
-	    description: Некорректные данные
+	    description: Некорректные данные, несуществующая фича или содержимое не соответствует схеме фичи
 	    content:
 	      application/json:
 	        schema:
@@ -346,10 +451,39 @@ summary: Создание нового баннера
 	          properties:
 	            error:
 	              type: string
+	            violations:
+	              type: array
+	              items:
+	                type: object
+	                properties:
+	                  pointer:
+	                    type: string
+	                  message:
+	                    type: string
 	  '401':
 	    description: Пользователь не авторизован
 	  '403':
 	    description: Пользователь не имеет доступа
+	  '409':
+	    description: Для фичи и тэга уже существует баннер
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            error:
+	              type: string
+	            conflicts:
+	              type: array
+	              items:
+	                type: object
+	                properties:
+	                  banner_id:
+	                    type: integer
+	                  feature_id:
+	                    type: integer
+	                  tag_id:
+	                    type: integer
 	  '500':
 	    description: Внутренняя ошибка сервера
 	    content:
@@ -384,10 +518,20 @@ func (h Handler) addBanner(c *gin.Context) { // POST /banner
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field tag_ids is not set"})
 		return
 	}
+	if !validWindow(req.BannerCommon) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field active_from must be earlier than active_until"})
+		return
+	}
 
-	banner, err := h.bannerService.Create(req)
+	data, _ := c.Get(authusertag)
+	user := data.(models.User)
+
+	banner, err := h.bannerService.Create(req, user.Name)
 	if err != nil {
-		h.logger.Error("can't parse request body from json: %v", err.Cause().Error())
+		h.logger.Error("can't create banner: %v", err.Cause().Error())
+		if abortOnConflict(c, err) || abortOnInvalidContent(c, err) {
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusInternalServerError, service.ErrDefaultInternalError.Error())
 		return
 	}
@@ -438,11 +582,21 @@ summary: Обновление содержимого баннера
 	            nullable: true
 	            type: boolean
 	            description: Флаг активности баннера
+	          active_from:
+	            nullable: true
+	            type: string
+	            format: date-time
+	            description: Начало показа баннера, null снимает ограничение
+	          active_until:
+	            nullable: true
+	            type: string
+	            format: date-time
+	            description: Окончание показа баннера, null снимает ограничение
 	responses:
 	  '200':
 	    description: OK
 	  '400':
-	    description: Некорректные данные
+	    description: Некорректные данные, несуществующая фича, содержимое не соответствует схеме фичи или начало показа не раньше окончания
 	    content:
 	      application/json:
 	        schema:
@@ -450,12 +604,23 @@ summary: Обновление содержимого баннера
 	          properties:
 	            error:
 	              type: string
+	            violations:
+	              type: array
+	              items:
+	                type: object
+	                properties:
+	                  pointer:
+	                    type: string
+	                  message:
+	                    type: string
 	  '401':
 	    description: Пользователь не авторизован
 	  '403':
 	    description: Пользователь не имеет доступа
 	  '404':
 	    description: Баннер не найден
+	  '409':
+	    description: Для фичи и тэга уже существует баннер
 	  '500':
 	    description: Внутренняя ошибка сервера
 	    content:
@@ -468,25 +633,43 @@ summary: Обновление содержимого баннера
 */
 func (h Handler) updateBanner(c *gin.Context) { // PATCH /banner/{id}
 	var req requests.UpdateBannerRequest
+	var bounds struct {
+		ActiveFrom  json.RawMessage `json:"active_from"`
+		ActiveUntil json.RawMessage `json:"active_until"`
+	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		h.logger.Error("can't parse request body from json: %v", err.Error())
 		c.AbortWithStatusJSON(http.StatusInternalServerError, service.ErrDefaultInternalError.Error())
 		return
 	}
+	// null and a missing field both decode to nil, only the raw value tells a bound to clear from one to keep
+	if err := c.ShouldBindBodyWith(&bounds, binding.JSON); err == nil {
+		req.ClearActiveFrom = string(bounds.ActiveFrom) == "null"
+		req.ClearActiveUntil = string(bounds.ActiveUntil) == "null"
+	}
 	if id, err := strconv.Atoi(c.Param("id")); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
 		return
 	} else {
 		req.ID = id
 	}
+	if !validWindow(req.BannerCommon) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field active_from must be earlier than active_until"})
+		return
+	}
+
+	data, _ := c.Get(authusertag)
+	user := data.(models.User)
 
-	err := h.bannerService.Update(req)
+	err := h.bannerService.Update(req, user.Name)
 	if err != nil {
 		h.logger.Error("can't update banner in database: %v", err.Cause().Error())
 		if errors.Is(err.Cause(), service.ErrBannerNotFound) {
 			c.AbortWithStatus(http.StatusNotFound)
-		} else {
+		} else if errors.Is(err.Cause(), service.ErrInvalidWindow) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Cause().Error()})
+		} else if !abortOnConflict(c, err) && !abortOnInvalidContent(c, err) {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, service.ErrDefaultInternalError.Error())
 		}
 		return
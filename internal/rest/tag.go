@@ -0,0 +1,351 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func (h Handler) abortTagError(c *gin.Context, err service.Error) {
+	switch {
+	case errors.Is(err.Cause(), service.ErrTagNotFound):
+		c.AbortWithStatus(http.StatusNotFound)
+	case errors.Is(err.Cause(), service.ErrTagAlreadyExists), errors.Is(err.Cause(), service.ErrTagInUse):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Cause().Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+	}
+}
+
+/*
+summary: Получение списка тэгов
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: limit
+	    required: false
+	    schema:
+	      type: integer
+	      description: Лимит
+	  - in: query
+	    name: offset
+	    required: false
+	    schema:
+	      type: integer
+	      description: Оффсет
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: array
+	          items:
+	            type: object
+	            properties:
+	              id:
+	                type: integer
+	                description: Идентификатор тэга
+	              name:
+	                type: string
+	                description: Название тэга
+	              banners_count:
+	                type: integer
+	                description: Количество баннеров с тэгом
+	              users_count:
+	                type: integer
+	                description: Количество пользователей с тэгом
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) getTags(c *gin.Context) { // GET /tag
+	var req requests.GetTagsRequest
+
+	if limit, ok := c.GetQuery("limit"); ok {
+		if val, err := strconv.Atoi(limit); err != nil {
+			h.logger.Info("can't parse limit: %v", err.Error())
+		} else {
+			req.Limit = val
+		}
+	}
+	if offset, ok := c.GetQuery("offset"); ok {
+		if val, err := strconv.Atoi(offset); err != nil {
+			h.logger.Info("can't parse offset: %v", err.Error())
+		} else {
+			req.Offset = val
+		}
+	}
+
+	tags, err := h.tagService.Get(req)
+	if err != nil {
+		h.logger.Error("can't get tags: %v", err.Cause().Error())
+		h.abortTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+/*
+summary: Получение тэга по идентификатору
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор тэга
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Тэг не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) getTag(c *gin.Context) { // GET /tag/{id}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	}
+
+	tag, serr := h.tagService.GetOne(requests.GetTagRequest{ID: id})
+	if serr != nil {
+		h.logger.Error("can't get tag: %v", serr.Cause().Error())
+		h.abortTagError(c, serr)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+/*
+summary: Создание нового тэга
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          name:
+	            type: string
+	            description: Название тэга
+	responses:
+	  '201':
+	    description: Created
+	    content:
+	      application/json:
+	        schema:
+	          type: object
+	          properties:
+	            tag_id:
+	              type: integer
+	              description: Идентификатор созданного тэга
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '409':
+	    description: Тэг с таким названием уже существует
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) addTag(c *gin.Context) { // POST /tag
+	var req requests.CreateTagRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field name is empty"})
+		return
+	}
+
+	tag, err := h.tagService.Create(req)
+	if err != nil {
+		h.logger.Error("can't create tag: %v", err.Cause().Error())
+		h.abortTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, requests.CreateTagResponse{TagID: tag.ID})
+}
+
+/*
+summary: Переименование тэга
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор тэга
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          name:
+	            type: string
+	            description: Новое название тэга
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Тэг не найден
+	  '409':
+	    description: Тэг с таким названием уже существует
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) updateTag(c *gin.Context) { // PATCH /tag/{id}
+	var req requests.UpdateTagRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if id, err := strconv.Atoi(c.Param("id")); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	} else {
+		req.ID = id
+	}
+	if req.Name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field name is empty"})
+		return
+	}
+
+	if err := h.tagService.Update(req); err != nil {
+		h.logger.Error("can't update tag: %v", err.Cause().Error())
+		h.abortTagError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/*
+summary: Удаление тэга по идентификатору
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор тэга
+	  - in: query
+	    name: cascade
+	    required: false
+	    schema:
+	      type: boolean
+	      default: false
+	      description: Снять тэг с баннеров и пользователей перед удалением, баннеры без других тэгов удаляются
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '204':
+	    description: Тэг успешно удален
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Тэг не найден
+	  '409':
+	    description: Тэг используется, а cascade не указан
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) deleteTag(c *gin.Context) { // DELETE /tag/{id}
+	var req requests.DeleteTagRequest
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return
+	}
+	req.ID = id
+	if cascade, ok := c.GetQuery("cascade"); ok {
+		if val, err := strconv.ParseBool(cascade); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "cascade parameter is not a boolean type"})
+			return
+		} else {
+			req.Cascade = val
+		}
+	}
+
+	data, _ := c.Get(authusertag)
+	user := data.(models.User)
+
+	if err := h.tagService.Delete(req, user.Name); err != nil {
+		h.logger.Error("can't delete tag: %v", err.Cause().Error())
+		h.abortTagError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,501 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func (h Handler) abortUserError(c *gin.Context, err service.Error) {
+	switch {
+	case errors.Is(err.Cause(), service.ErrUserNotFound):
+		c.AbortWithStatus(http.StatusNotFound)
+	case errors.Is(err.Cause(), service.ErrUsernameAlreadyExists):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Cause().Error()})
+	case errors.Is(err.Cause(), service.ErrUnknownTags),
+		errors.Is(err.Cause(), service.ErrPasswordTooShort), errors.Is(err.Cause(), service.ErrPasswordTooLong):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Cause().Error()})
+	case errors.Is(err.Cause(), service.ErrPasswordForbidden), errors.Is(err.Cause(), service.ErrInvalidCredentials),
+		errors.Is(err.Cause(), service.ErrPasswordNotSet):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Cause().Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+	}
+}
+
+/*
+summary: Получение списка пользователей
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	  - in: query
+	    name: limit
+	    required: false
+	    schema:
+	      type: integer
+	      description: Лимит
+	  - in: query
+	    name: offset
+	    required: false
+	    schema:
+	      type: integer
+	      description: Оффсет
+	responses:
+	  '200':
+	    description: OK
+	    content:
+	      application/json:
+	        schema:
+	          type: array
+	          items:
+	            type: object
+	            properties:
+	              name:
+	                type: string
+	                description: Имя пользователя
+	              is_admin:
+	                type: boolean
+	                description: Флаг администратора
+	              tags:
+	                type: array
+	                description: Тэги пользователя
+	                items:
+	                  type: integer
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) getUsers(c *gin.Context) { // GET /user
+	var req requests.GetUsersRequest
+
+	if limit, ok := c.GetQuery("limit"); ok {
+		if val, err := strconv.Atoi(limit); err != nil {
+			h.logger.Info("can't parse limit: %v", err.Error())
+		} else {
+			req.Limit = val
+		}
+	}
+	if offset, ok := c.GetQuery("offset"); ok {
+		if val, err := strconv.Atoi(offset); err != nil {
+			h.logger.Info("can't parse offset: %v", err.Error())
+		} else {
+			req.Offset = val
+		}
+	}
+
+	users, err := h.userService.Get(req)
+	if err != nil {
+		h.logger.Error("can't get users: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+/*
+summary: Создание нового пользователя
+
+	parameters:
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          name:
+	            type: string
+	            description: Имя пользователя
+	          is_admin:
+	            type: boolean
+	            description: Флаг администратора
+	          tags:
+	            type: array
+	            description: Тэги пользователя
+	            items:
+	              type: integer
+	responses:
+	  '201':
+	    description: Created
+	  '400':
+	    description: Некорректные данные или несуществующие тэги
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '409':
+	    description: Пользователь с таким именем уже существует
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) addUser(c *gin.Context) { // POST /user
+	var req requests.CreateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field name is empty"})
+		return
+	}
+
+	if err := h.userService.Create(req); err != nil {
+		h.logger.Error("can't create user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+/*
+summary: Изменение прав пользователя
+
+	parameters:
+	  - in: path
+	    name: name
+	    required: true
+	    schema:
+	      type: string
+	      description: Имя пользователя
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          is_admin:
+	            type: boolean
+	            description: Флаг администратора
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Пользователь не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) updateUser(c *gin.Context) { // PATCH /user/{name}
+	var req requests.UpdateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = c.Param("name")
+	if req.IsAdmin == nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field is_admin is not set"})
+		return
+	}
+
+	if err := h.userService.Update(req); err != nil {
+		h.logger.Error("can't update user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h Handler) bindUserTags(c *gin.Context) (requests.UserTagsRequest, bool) {
+	var req requests.UserTagsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	req.Name = c.Param("name")
+	if len(req.Tags) == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "field tags is empty"})
+		return req, false
+	}
+	return req, true
+}
+
+/*
+summary: Добавление тэгов пользователю
+
+	parameters:
+	  - in: path
+	    name: name
+	    required: true
+	    schema:
+	      type: string
+	      description: Имя пользователя
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          tags:
+	            type: array
+	            description: Добавляемые тэги
+	            items:
+	              type: integer
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные или несуществующие тэги
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Пользователь не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) addUserTags(c *gin.Context) { // POST /user/{name}/tags
+	req, ok := h.bindUserTags(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.AddTags(req); err != nil {
+		h.logger.Error("can't add tags to user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/*
+summary: Удаление тэгов пользователя
+
+	parameters:
+	  - in: path
+	    name: name
+	    required: true
+	    schema:
+	      type: string
+	      description: Имя пользователя
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          tags:
+	            type: array
+	            description: Удаляемые тэги
+	            items:
+	              type: integer
+	responses:
+	  '200':
+	    description: OK
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Пользователь не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) removeUserTags(c *gin.Context) { // DELETE /user/{name}/tags
+	req, ok := h.bindUserTags(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.RemoveTags(req); err != nil {
+		h.logger.Error("can't remove tags from user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/*
+summary: Установка или смена пароля пользователя
+
+	parameters:
+	  - in: path
+	    name: name
+	    required: true
+	    schema:
+	      type: string
+	      description: Имя пользователя
+	  - in: header
+	    name: token
+	    description: Токен пользователя или админа
+	    schema:
+	      type: string
+	      example: "user_token"
+	requestBody:
+	  required: true
+	  content:
+	    application/json:
+	      schema:
+	        type: object
+	        properties:
+	          current_password:
+	            type: string
+	            description: Текущий пароль, нужен, когда пользователь меняет свой пароль, админу не нужен
+	          password:
+	            type: string
+	            description: Новый пароль, от 8 до 72 байт
+	responses:
+	  '204':
+	    description: Пароль установлен, все выданные пользователю токены отозваны
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Чужой пароль и первый пароль пользователя может задать только админ, или текущий пароль неверен
+	  '404':
+	    description: Пользователь не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) setUserPassword(c *gin.Context) { // PUT /user/{name}/password
+	var req requests.SetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = c.Param("name")
+
+	data, _ := c.Get(authusertag)
+	user := data.(models.User)
+
+	if err := h.userService.SetPassword(req, user); err != nil {
+		h.logger.Error("can't set password of user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+/*
+summary: Отзыв всех токенов пользователя
+
+	parameters:
+	  - in: path
+	    name: name
+	    required: true
+	    schema:
+	      type: string
+	      description: Имя пользователя
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '204':
+	    description: Все выданные пользователю токены отозваны
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Пользователь не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) revokeUser(c *gin.Context) { // POST /user/{name}/revoke
+	if err := h.userService.Revoke(requests.RevokeUserRequest{Name: c.Param("name")}); err != nil {
+		h.logger.Error("can't revoke tokens of user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+/*
+summary: Удаление пользователя
+
+	parameters:
+	  - in: path
+	    name: name
+	    required: true
+	    schema:
+	      type: string
+	      description: Имя пользователя
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '204':
+	    description: Пользователь успешно удален
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Пользователь не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) deleteUser(c *gin.Context) { // DELETE /user/{name}
+	if err := h.userService.Delete(requests.DeleteUserRequest{Name: c.Param("name")}); err != nil {
+		h.logger.Error("can't delete user: %v", err.Cause().Error())
+		h.abortUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,225 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func (h Handler) versionParams(c *gin.Context) (requests.BannerVersionRequest, bool) {
+	var req requests.BannerVersionRequest
+	if id, err := strconv.Atoi(c.Param("id")); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "id parameter is not an integer type"})
+		return req, false
+	} else {
+		req.BannerID = id
+	}
+	if n, ok := c.Params.Get("n"); ok {
+		if val, err := strconv.Atoi(n); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "version parameter is not an integer type"})
+			return req, false
+		} else {
+			req.Version = val
+		}
+	}
+	return req, true
+}
+
+/*
+summary: Получение истории версий баннера, в том числе удаленного
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор баннера
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '200':
+	    description: Версии баннера, начиная с последней
+	    content:
+	      application/json:
+	        schema:
+	          type: array
+	          items:
+	            type: object
+	            properties:
+	              banner_id:
+	                type: integer
+	              version:
+	                type: integer
+	              tag_ids:
+	                type: array
+	                items:
+	                  type: integer
+	              feature_id:
+	                type: integer
+	              content:
+	                type: object
+	                additionalProperties: true
+	              is_active:
+	                type: boolean
+	              author:
+	                type: string
+	              created_at:
+	                type: string
+	                format: date-time
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Баннер не найден
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) bannerVersions(c *gin.Context) { // GET /banner/{id}/versions
+	params, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.bannerService.Versions(requests.BannerVersionsRequest{BannerID: params.BannerID})
+	if err != nil {
+		h.logger.Error("can't get banner versions: %v", err.Cause().Error())
+		if errors.Is(err.Cause(), service.ErrBannerNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+		} else {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+/*
+summary: Получение конкретной версии баннера
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор баннера
+	  - in: path
+	    name: n
+	    required: true
+	    schema:
+	      type: integer
+	      description: Номер версии
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '200':
+	    description: Версия баннера
+	  '400':
+	    description: Некорректные данные
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Версия баннера не найдена
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) bannerVersion(c *gin.Context) { // GET /banner/{id}/versions/{n}
+	req, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+
+	version, err := h.bannerService.Version(req)
+	if err != nil {
+		h.logger.Error("can't get banner version: %v", err.Cause().Error())
+		if errors.Is(err.Cause(), service.ErrBannerVersionNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+		} else {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+/*
+summary: Откат баннера к указанной версии
+
+	parameters:
+	  - in: path
+	    name: id
+	    required: true
+	    schema:
+	      type: integer
+	      description: Идентификатор баннера
+	  - in: path
+	    name: n
+	    required: true
+	    schema:
+	      type: integer
+	      description: Номер версии
+	  - in: header
+	    name: token
+	    description: Токен админа
+	    schema:
+	      type: string
+	      example: "admin_token"
+	responses:
+	  '200':
+	    description: Баннер восстановлен или создан заново, если был удален, создана новая версия
+	  '400':
+	    description: Некорректные данные или содержимое версии не соответствует текущей схеме фичи
+	  '401':
+	    description: Пользователь не авторизован
+	  '403':
+	    description: Пользователь не имеет доступа
+	  '404':
+	    description: Версия баннера не найдена
+	  '409':
+	    description: Для фичи и тэга версии уже существует другой баннер, или фича либо тэги версии удалены
+	  '500':
+	    description: Внутренняя ошибка сервера
+*/
+func (h Handler) restoreBannerVersion(c *gin.Context) { // POST /banner/{id}/versions/{n}/restore
+	params, ok := h.versionParams(c)
+	if !ok {
+		return
+	}
+	data, _ := c.Get(authusertag)
+	user := data.(models.User)
+
+	if err := h.bannerService.Restore(requests.RestoreBannerVersionRequest{BannerVersionRequest: params}, user.Name); err != nil {
+		h.logger.Error("can't restore banner version: %v", err.Cause().Error())
+		if errors.Is(err.Cause(), service.ErrBannerVersionNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+		} else if errors.Is(err.Cause(), service.ErrVersionRefsMissing) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Cause().Error()})
+		} else if !abortOnConflict(c, err) && !abortOnInvalidContent(c, err) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": service.ErrDefaultInternalError.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
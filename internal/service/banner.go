@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/antsrp/banner_service/internal/cache"
@@ -11,49 +14,201 @@ import (
 	"github.com/antsrp/banner_service/internal/domain/models/requests"
 	"github.com/antsrp/banner_service/internal/repository"
 	"github.com/antsrp/banner_service/internal/repository/postgres"
+	"github.com/antsrp/banner_service/pkg/jsonschema"
 	"github.com/antsrp/banner_service/pkg/logger"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// deleteBatchSize is how many banners a bulk delete removes per statement.
+	deleteBatchSize = 100
+	// deleteRetries is how many times a bulk delete waits for locked banners or for the cache before it fails.
+	deleteRetries = 5
+	// deleteRetryDelay is the pause before the first retry, every next one is twice as long.
+	deleteRetryDelay = 100 * time.Millisecond
 )
 
 type BannerServicer interface {
-	GetOne(requests.UserBannerRequest, string) (models.Banner, Error)
+	GetOne(context.Context, requests.UserBannerRequest, models.User) (models.Banner, Error)
 	Get(requests.GetBannersRequest) ([]models.Banner, Error)
-	Create(requests.CreateBannerRequest) (models.Banner, Error)
-	Update(requests.UpdateBannerRequest) Error
+	Create(requests.CreateBannerRequest, string) (models.Banner, Error)
+	Update(requests.UpdateBannerRequest, string) Error
 	Delete(requests.DeleteBannerRequest) Error
+	DeleteMany(requests.DeleteBannersRequest) (string, Error)
+
+	Versions(requests.BannerVersionsRequest) ([]models.BannerVersion, Error)
+	Version(requests.BannerVersionRequest) (models.BannerVersion, Error)
+	Restore(requests.RestoreBannerVersionRequest, string) Error
+
+	Export(requests.ExportBannersRequest, func(models.Banner) error) Error
+	Changes(time.Time) (models.BannerChanges, Error)
+
+	Cached(requests.CachedBannerRequest) (models.CachedBanner, Error)
+	Evict(requests.CachedBannerRequest) Error
+	Import(requests.ImportBannersRequest, string) (models.ImportReport, Error)
 }
 
 type BannerService struct {
-	storage      postgres.BannerStorage
-	cacheStorage cache.Storager[models.Banner]
-	logger       logger.Logger
+	storage        postgres.BannerStorage
+	featureStorage repository.FeatureStorage
+	cacheStorage   cache.Storager[models.Banner]
+	keys           cache.KeyBuilder
+	jobService     *JobService
+	group          *singleflight.Group
+	logger         logger.Logger
 }
 
-func NewBannerService(storage postgres.BannerStorage, cs cache.Storager[models.Banner], logger logger.Logger) BannerService {
+func NewBannerService(storage postgres.BannerStorage, fs repository.FeatureStorage, cs cache.Storager[models.Banner], keys cache.KeyBuilder, js *JobService, logger logger.Logger) BannerService {
 	return BannerService{
-		storage:      storage,
-		cacheStorage: cs,
-		logger:       logger,
+		storage:        storage,
+		featureStorage: fs,
+		cacheStorage:   cs,
+		keys:           keys,
+		jobService:     js,
+		group:          &singleflight.Group{},
+		logger:         logger,
 	}
 }
 
-func (s BannerService) GetOne(req requests.UserBannerRequest, userName string) (models.Banner, Error) {
-	var banner models.Banner
-	if req.IsUseLastRevision { // find in cache
-		var err error
-		banner, err = s.cacheStorage.Get(fmt.Sprintf("(%d, %d)", req.FeatureID, req.TagID))
-		if err != nil {
-			return models.Banner{}, NewServiceError(false, ErrBannerNotFound)
+// validateContent checks the banner content against the schema of its feature.
+func (s BannerService) validateContent(featureID int, content models.BannerContent) Error {
+	schema, err := featureSchema(context.Background(), s.featureStorage, featureID)
+	if err != nil {
+		return err
+	}
+	return checkContent(schema, content)
+}
+
+// authorize checks that a non-admin user owns the requested tag. The tags come from the token,
+// so the check costs no database query.
+func authorize(user models.User, tagID int) Error {
+	if user.IsAdmin || slices.Contains(user.Tags, tagID) {
+		return nil
+	}
+	return NewServiceError(false, ErrTagForbidden)
+}
+
+// GetOne checks access before anything is looked up, so a user can't tell from the answer
+// whether a banner exists for a tag they don't own, and such requests never reach the storage.
+func (s BannerService) GetOne(ctx context.Context, req requests.UserBannerRequest, user models.User) (models.Banner, Error) {
+	if err := authorize(user, req.TagID); err != nil {
+		return models.Banner{}, err
+	}
+	return s.resolveOne(ctx, req)
+}
+
+func (s BannerService) resolveOne(ctx context.Context, req requests.UserBannerRequest) (models.Banner, Error) {
+	opts := repository.GetBanner{FeatureID: req.FeatureID, TagID: req.TagID}
+	if !req.IsUseLastRevision {
+		return s.getOne(ctx, opts)
+	}
+
+	key := s.keys.Banner(req.FeatureID, req.TagID)
+	banner, err := s.cacheStorage.Get(ctx, key)
+	if err == nil && banner.Status(time.Now()) == models.BannerStatusLive {
+		return banner, nil
+	}
+	if err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		s.logger.Error("can't get banner from cache: %v", err.Error())
+	}
+
+	// concurrent misses of the same key wait for a single query, which must not fail
+	// for all of them when the request that started it goes away
+	shared := context.WithoutCancel(ctx)
+	result, _, _ := s.group.Do(key, func() (any, error) {
+		banner, err := s.getOne(shared, opts)
+		if err == nil {
+			if err := s.cacheStorage.Set(shared, key, banner); err != nil {
+				s.logger.Error("can't put banner into cache: %v", err.Error())
+			}
 		}
-	} else {
-		var err Error
-		banner, err = s.storage.GetOne(context.Background(), repository.GetBanner{FeatureID: req.FeatureID, TagID: req.TagID}, userName)
-		if err != nil {
-			s.logger.Error("can't get banner: %v", err.Cause().Error())
-			if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
-				return models.Banner{}, NewServiceError(false, ErrBannerNotFound)
+		return bannerResult{banner: banner, err: err}, nil
+	})
+	r := result.(bannerResult)
+	return r.banner, r.err
+}
+
+// Cached shows what the cache holds for the pair and what the database would return instead.
+func (s BannerService) Cached(req requests.CachedBannerRequest) (models.CachedBanner, Error) {
+	ctx := context.Background()
+	result := models.CachedBanner{Key: s.keys.Banner(req.FeatureID, req.TagID)}
+
+	cached, err := s.cacheStorage.Get(ctx, result.Key)
+	switch {
+	case err == nil:
+		result.Cached = &cached
+		if ttler, ok := s.cacheStorage.(cache.TTLer); ok {
+			if ttl, err := ttler.TTL(ctx, result.Key); err == nil {
+				seconds := int64(ttl.Seconds())
+				result.TTLSeconds = &seconds
 			}
-			return models.Banner{}, err
 		}
+	case !errors.Is(err, cache.ErrKeyNotFound):
+		s.logger.Error("can't get banner from cache: %v", err.Error())
+		return models.CachedBanner{}, NewServiceError(true, fmt.Errorf("can't get banner from cache: %w", err))
+	}
+
+	banner, serr := s.getOne(ctx, repository.GetBanner{FeatureID: req.FeatureID, TagID: req.TagID})
+	switch {
+	case serr == nil:
+		result.Database = &banner
+	case !errors.Is(serr.Cause(), ErrBannerNotFound):
+		return models.CachedBanner{}, serr
+	}
+
+	result.Differ = !sameBanner(result.Cached, result.Database)
+	return result, nil
+}
+
+func (s BannerService) Evict(req requests.CachedBannerRequest) Error {
+	if err := s.cacheStorage.Delete(context.Background(), s.keys.Banner(req.FeatureID, req.TagID)); err != nil {
+		s.logger.Error("can't delete banner from cache: %v", err.Error())
+		return NewServiceError(true, fmt.Errorf("can't delete banner from cache: %w", err))
+	}
+	return nil
+}
+
+// sameBanner compares banners field by field, times are compared as instants
+// because a cached banner comes back from json in another location.
+func sameBanner(a, b *models.Banner) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	sameTime := func(x, y *time.Time) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.Equal(*y)
+	}
+	sameFlag := func(x, y *bool) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return *x == *y
+	}
+	return a.ID == b.ID && a.FeatureID == b.FeatureID && slices.Equal(a.TagIDS, b.TagIDS) &&
+		reflect.DeepEqual(a.Content, b.Content) && sameFlag(a.IsActive, b.IsActive) &&
+		sameTime(a.ActiveFrom, b.ActiveFrom) && sameTime(a.ActiveUntil, b.ActiveUntil) &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+// bannerResult carries a service error through singleflight, which only knows plain errors.
+type bannerResult struct {
+	banner models.Banner
+	err    Error
+}
+
+func (s BannerService) getOne(ctx context.Context, opts repository.GetBanner) (models.Banner, Error) {
+	banner, err := s.storage.GetOne(ctx, opts)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return models.Banner{}, NewServiceError(false, ErrBannerNotFound)
+		}
+		s.logger.Error("can't get banner: %v", err.Cause().Error())
+		if err.IsInternal() {
+			return models.Banner{}, defaultInternalError
+		}
+		return models.Banner{}, NewServiceError(true, err.Cause())
 	}
 	return banner, nil
 }
@@ -63,6 +218,7 @@ func (s BannerService) Get(req requests.GetBannersRequest) ([]models.Banner, Err
 			FeatureID: req.FeatureID,
 			TagID:     req.TagID,
 		},
+		Status: req.Status,
 		Limit:  req.Limit,
 		Offset: req.Offset,
 	})
@@ -74,36 +230,95 @@ func (s BannerService) Get(req requests.GetBannersRequest) ([]models.Banner, Err
 	}
 	return banners, nil
 }
-func (s BannerService) Create(req requests.CreateBannerRequest) (models.Banner, Error) {
+func (s BannerService) Create(req requests.CreateBannerRequest, author string) (models.Banner, Error) {
+	if err := s.validateContent(req.FeatureID, req.Content); err != nil {
+		return models.Banner{}, err
+	}
 	banner, err := s.storage.Create(context.Background(), models.Banner{
 		BannerCommon: req.BannerCommon,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
-	})
+	}, author)
 	if err != nil {
+		if conflict, ok := conflictError(err); ok {
+			return models.Banner{}, conflict
+		}
 		if err.IsInternal() {
 			return models.Banner{}, defaultInternalError
 		}
 		return models.Banner{}, NewServiceError(true, err.Cause())
 	}
+	s.syncCache(nil, banner.ID)
 	return banner, nil
 }
-func (s BannerService) Update(req requests.UpdateBannerRequest) Error {
-	if err := s.storage.Update(context.Background(), models.Banner{
-		BannerCommon: req.BannerCommon,
-		UpdatedAt:    time.Now(),
-	}); err != nil {
-		if errors.Is(err.Cause(), repository.ErrNoRowsAffected) {
+
+// checkUpdate validates a partial update against the state it leaves the banner in.
+func (s BannerService) checkUpdate(req requests.UpdateBannerRequest, current models.Banner) Error {
+	if req.FeatureID != 0 || req.Content != nil {
+		featureID, content := req.FeatureID, req.Content
+		if featureID == 0 {
+			featureID = current.FeatureID
+		}
+		if content == nil {
+			content = current.Content
+		}
+		if err := s.validateContent(featureID, content); err != nil {
+			return err
+		}
+	}
+	// a bound left out of the request keeps the stored value
+	activeFrom, activeUntil := current.ActiveFrom, current.ActiveUntil
+	if req.ActiveFrom != nil || req.ClearActiveFrom {
+		activeFrom = req.ActiveFrom
+	}
+	if req.ActiveUntil != nil || req.ClearActiveUntil {
+		activeUntil = req.ActiveUntil
+	}
+	if activeFrom != nil && activeUntil != nil && !activeFrom.Before(*activeUntil) {
+		return NewServiceError(false, ErrInvalidWindow)
+	}
+	return nil
+}
+func (s BannerService) Update(req requests.UpdateBannerRequest, author string) Error {
+	// the check runs on the row locked by the update, so a concurrent change can't slip in between
+	var invalid Error
+	before, err := s.storage.Update(context.Background(), repository.UpdateBanner{
+		Banner: models.Banner{
+			BannerCommon: req.BannerCommon,
+			UpdatedAt:    time.Now(),
+		},
+		ClearActiveFrom:  req.ClearActiveFrom,
+		ClearActiveUntil: req.ClearActiveUntil,
+		Check: func(current models.Banner) error {
+			if invalid = s.checkUpdate(req, current); invalid != nil {
+				return invalid.Cause()
+			}
+			return nil
+		},
+	}, author)
+	if err != nil {
+		if invalid != nil {
+			return invalid
+		}
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
 			return NewServiceError(false, ErrBannerNotFound)
 		}
+		if conflict, ok := conflictError(err); ok {
+			return conflict
+		}
 		if err.IsInternal() {
 			return defaultInternalError
 		}
 		return NewServiceError(true, err.Cause())
 	}
+	s.syncCache(&before, req.ID)
 	return nil
 }
 func (s BannerService) Delete(req requests.DeleteBannerRequest) Error {
+	var before *models.Banner
+	if current, err := s.storage.GetByID(context.Background(), req.ID); err == nil {
+		before = &current
+	}
 	if err := s.storage.Delete(context.Background(), req.ID); err != nil {
 		if errors.Is(err.Cause(), repository.ErrNoRowsAffected) {
 			return NewServiceError(false, ErrBannerNotFound)
@@ -113,7 +328,344 @@ func (s BannerService) Delete(req requests.DeleteBannerRequest) Error {
 		}
 		return NewServiceError(true, err.Cause())
 	}
+	s.syncCache(before, req.ID)
+	return nil
+}
+
+// syncCache brings the cache in line with the banner after it was changed: keys of tags the banner
+// no longer has are evicted, its own keys are rewritten while it is live and evicted otherwise.
+// before is the banner as it was, nil for a new one. Cache errors are only logged, the change itself is already saved.
+func (s BannerService) syncCache(before *models.Banner, id int) {
+	var after *models.Banner
+	banner, err := s.storage.GetByID(context.Background(), id)
+	switch {
+	case err == nil:
+		after = &banner
+	case !errors.Is(err.Cause(), repository.ErrEntityNotFound):
+		s.logger.Error("can't get banner %d to update cache: %v", id, err.Cause().Error())
+	}
+
+	set := make(map[string]models.Banner)
+	var evict []string
+	if after != nil {
+		live := after.Status(time.Now()) == models.BannerStatusLive
+		for _, tag := range after.TagIDS {
+			key := s.keys.Banner(after.FeatureID, tag)
+			if live {
+				set[key] = *after
+			} else {
+				evict = append(evict, key)
+			}
+		}
+	}
+	if before != nil {
+		for _, tag := range before.TagIDS {
+			key := s.keys.Banner(before.FeatureID, tag)
+			if _, ok := set[key]; !ok && !slices.Contains(evict, key) {
+				evict = append(evict, key)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	if len(evict) != 0 {
+		if err := s.cacheStorage.DeleteMany(ctx, evict); err != nil {
+			s.logger.Error("can't delete banner %d from cache: %v", id, err.Error())
+		}
+	}
+	if len(set) != 0 {
+		if err := s.cacheStorage.MSet(ctx, set); err != nil {
+			s.logger.Error("can't put banner %d into cache: %v", id, err.Error())
+		}
+	}
+}
+
+// evictPairs drops cached banners of (feature, tag) pairs changed in bulk, where syncing every banner
+// isn't worth it. Cache errors are only logged like in syncCache.
+func evictPairs(cs cache.Storager[models.Banner], keys cache.KeyBuilder, pairs []models.BannerKey, logger logger.Logger) {
+	if len(pairs) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(pairs))
+	evict := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		key := keys.Banner(pair.FeatureID, pair.TagID)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			evict = append(evict, key)
+		}
+	}
+	if err := cs.DeleteMany(context.Background(), evict); err != nil {
+		logger.Error("can't delete %d banners from cache: %v", len(evict), err.Error())
+	}
+}
+
+// DeleteMany starts a job deleting all banners of the feature and/or the tag and returns its id.
+func (s BannerService) DeleteMany(req requests.DeleteBannersRequest) (string, Error) {
+	opts := repository.GetBanner{FeatureID: req.FeatureID, TagID: req.TagID}
+	total, err := s.storage.Count(context.Background(), opts)
+	if err != nil {
+		if err.IsInternal() {
+			return "", defaultInternalError
+		}
+		return "", NewServiceError(true, err.Cause())
+	}
+	id, jerr := s.jobService.start("delete_banners", total)
+	if jerr != nil {
+		return "", NewServiceError(true, jerr)
+	}
+	go s.deleteMany(id, opts)
+	return id, nil
+}
+
+func (s BannerService) deleteMany(id string, opts repository.GetBanner) {
+	ctx := context.Background()
+	locked := 0
+	for {
+		banners, err := s.storage.DeleteBatch(ctx, opts, deleteBatchSize)
+		if err != nil {
+			s.logger.Error("job %s: can't delete banners: %v", id, err.Cause().Error())
+			s.jobService.finish(id, ErrDefaultInternalError)
+			return
+		}
+		if len(banners) == 0 {
+			// an empty batch may only mean the rest is locked by other requests
+			left, err := s.storage.Count(ctx, opts)
+			if err != nil {
+				s.logger.Error("job %s: can't count banners left: %v", id, err.Cause().Error())
+				s.jobService.finish(id, ErrDefaultInternalError)
+				return
+			}
+			if left == 0 {
+				break
+			}
+			if locked == deleteRetries {
+				s.logger.Error("job %s: %d banners are still locked, giving up", id, left)
+				s.jobService.finish(id, ErrBannersLocked)
+				return
+			}
+			time.Sleep(deleteRetryDelay << locked)
+			locked++
+			continue
+		}
+		locked = 0
+
+		var keys []string
+		for _, banner := range banners {
+			for _, tag := range banner.TagIDS {
+				keys = append(keys, s.keys.Banner(banner.FeatureID, tag))
+			}
+		}
+		if err := s.evictRetrying(ctx, keys); err != nil {
+			s.logger.Error("job %s: can't delete banners from cache: %v", id, err.Error())
+			s.jobService.progress(id, len(banners))
+			s.jobService.finish(id, ErrCacheUnavailable)
+			return
+		}
+		s.jobService.progress(id, len(banners))
+	}
+	s.jobService.finish(id, nil)
+}
+
+// evictRetrying deletes keys from the cache, retrying with a growing pause before it gives up.
+func (s BannerService) evictRetrying(ctx context.Context, keys []string) error {
+	var err error
+	for attempt := 0; attempt <= deleteRetries; attempt++ {
+		if attempt != 0 {
+			time.Sleep(deleteRetryDelay << (attempt - 1))
+		}
+		if err = s.cacheStorage.DeleteMany(ctx, keys); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+func (s BannerService) Versions(req requests.BannerVersionsRequest) ([]models.BannerVersion, Error) {
+	versions, err := s.storage.Versions(context.Background(), req.BannerID)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return nil, NewServiceError(false, ErrBannerNotFound)
+		}
+		if err.IsInternal() {
+			return nil, defaultInternalError
+		}
+		return nil, NewServiceError(true, err.Cause())
+	}
+	return versions, nil
+}
+func (s BannerService) Version(req requests.BannerVersionRequest) (models.BannerVersion, Error) {
+	version, err := s.storage.Version(context.Background(), repository.GetBannerVersion{
+		BannerID: req.BannerID,
+		Version:  req.Version,
+	})
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return models.BannerVersion{}, NewServiceError(false, ErrBannerVersionNotFound)
+		}
+		if err.IsInternal() {
+			return models.BannerVersion{}, defaultInternalError
+		}
+		return models.BannerVersion{}, NewServiceError(true, err.Cause())
+	}
+	return version, nil
+}
+
+// Restore brings the banner back to the version, recreating it when it was deleted. The content is
+// checked against the current schema of the feature first: it may have changed since the version was written.
+func (s BannerService) Restore(req requests.RestoreBannerVersionRequest, author string) Error {
+	version, err := s.Version(req.BannerVersionRequest)
+	if err != nil {
+		return err
+	}
+	if err := s.validateContent(version.FeatureID, version.Content); err != nil {
+		if errors.Is(err.Cause(), ErrFeatureNotFound) {
+			return NewServiceError(false, ErrVersionRefsMissing)
+		}
+		return err
+	}
+
+	var before *models.Banner
+	if current, err := s.storage.GetByID(context.Background(), req.BannerID); err == nil {
+		before = &current
+	}
+	if err := s.storage.Restore(context.Background(), repository.GetBannerVersion{
+		BannerID: req.BannerID,
+		Version:  req.Version,
+	}, author); err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return NewServiceError(false, ErrBannerVersionNotFound)
+		}
+		if errors.Is(err.Cause(), repository.ErrVersionRefsMissing) {
+			return NewServiceError(false, ErrVersionRefsMissing)
+		}
+		if conflict, ok := conflictError(err); ok {
+			return conflict
+		}
+		if err.IsInternal() {
+			return defaultInternalError
+		}
+		return NewServiceError(true, err.Cause())
+	}
+	s.syncCache(before, req.BannerID)
 	return nil
 }
 
+// Changes returns what happened to banners after since and forgets removals older than tombstoneRetention.
+func (s BannerService) Changes(since time.Time) (models.BannerChanges, Error) {
+	changes, err := s.storage.Changes(context.Background(), since)
+	if err != nil {
+		if err.IsInternal() {
+			return models.BannerChanges{}, defaultInternalError
+		}
+		return models.BannerChanges{}, NewServiceError(true, err.Cause())
+	}
+	if err := s.storage.PurgeTombstones(context.Background(), changes.Until.Add(-tombstoneRetention)); err != nil {
+		s.logger.Error("can't purge tombstones: %v", err.Cause().Error())
+	}
+	return changes, nil
+}
+func (s BannerService) Export(req requests.ExportBannersRequest, fn func(models.Banner) error) Error {
+	if err := s.storage.Iterate(context.Background(), repository.GetBannerLimited{
+		GetBanner: repository.GetBanner{
+			FeatureID: req.FeatureID,
+			TagID:     req.TagID,
+		},
+	}, fn); err != nil {
+		if err.IsInternal() {
+			return defaultInternalError
+		}
+		return NewServiceError(true, err.Cause())
+	}
+	return nil
+}
+func (s BannerService) Import(req requests.ImportBannersRequest, author string) (models.ImportReport, Error) {
+	lineErrors := append([]models.ImportLineError(nil), req.Errors...)
+	banners := make([]repository.ImportBanner, 0, len(req.Banners))
+	schemas := make(map[int]*jsonschema.Schema)
+	for _, line := range req.Banners {
+		if err := validateBanner(line.Banner); err != nil {
+			lineErrors = append(lineErrors, models.ImportLineError{Line: line.Line, Error: err.Error()})
+			continue
+		}
+		schema, ok := schemas[line.Banner.FeatureID]
+		if !ok {
+			var err Error
+			schema, err = featureSchema(context.Background(), s.featureStorage, line.Banner.FeatureID)
+			if err != nil && !errors.Is(err.Cause(), ErrFeatureNotFound) {
+				return models.ImportReport{}, err
+			}
+			// an unknown feature is reported by the storage like before
+			schemas[line.Banner.FeatureID] = schema
+		}
+		if err := checkContent(schema, line.Banner.Content); err != nil {
+			lineErrors = append(lineErrors, models.ImportLineError{Line: line.Line, Error: contentErrorMessage(err)})
+			continue
+		}
+		banners = append(banners, repository.ImportBanner{
+			Line:   line.Line,
+			Banner: models.Banner{BannerCommon: line.Banner},
+		})
+	}
+
+	report, err := s.storage.Import(context.Background(), banners, repository.ImportOptions{
+		Mode: req.Mode,
+		// nothing may be written if some lines are already known to be broken
+		DryRun: req.DryRun || len(lineErrors) != 0,
+		Author: author,
+	})
+	if err != nil {
+		if err.IsInternal() {
+			return models.ImportReport{}, defaultInternalError
+		}
+		return models.ImportReport{}, NewServiceError(true, err.Cause())
+	}
+	report.DryRun = req.DryRun
+	report.Errors = append(report.Errors, lineErrors...)
+	slices.SortFunc(report.Errors, func(a, b models.ImportLineError) int {
+		return a.Line - b.Line
+	})
+	if report.Applied {
+		evictPairs(s.cacheStorage, s.keys, report.Affected, s.logger)
+	}
+	return report, nil
+}
+
+// validateBanner checks the fields a new banner can't be created without.
+func validateBanner(banner models.BannerCommon) error {
+	switch {
+	case banner.Content == nil:
+		return fmt.Errorf("field content is empty")
+	case banner.IsActive == nil:
+		return fmt.Errorf("field is_active is not set")
+	case banner.FeatureID <= 0:
+		return fmt.Errorf("field feature_id is not set or set wrong")
+	case len(banner.TagIDS) == 0:
+		return fmt.Errorf("field tag_ids is not set")
+	case banner.ActiveFrom != nil && banner.ActiveUntil != nil && !banner.ActiveFrom.Before(*banner.ActiveUntil):
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+// contentErrorMessage puts schema violations into a single line of an import report.
+func contentErrorMessage(err Error) string {
+	var content ContentError
+	if !errors.As(err.Cause(), &content) {
+		return err.Cause().Error()
+	}
+	parts := make([]string, 0, len(content.Violations))
+	for _, v := range content.Violations {
+		parts = append(parts, fmt.Sprintf("%s: %s", v.Pointer, v.Message))
+	}
+	return fmt.Sprintf("%s: %s", content.Error(), strings.Join(parts, "; "))
+}
+
+func conflictError(err repository.DatabaseError) (Error, bool) {
+	var conflict repository.BannerConflictError
+	if !errors.As(err.Cause(), &conflict) {
+		return nil, false
+	}
+	return NewServiceError(false, BannerConflictError{Conflicts: conflict.Conflicts}), true
+}
+
 var _ BannerServicer = BannerService{}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/antsrp/banner_service/pkg/jsonschema"
+)
+
+// compileSchema checks a content schema coming from a client.
+func compileSchema(data []byte) (*jsonschema.Schema, Error) {
+	schema, err := jsonschema.Compile(data)
+	if err != nil {
+		return nil, NewServiceError(false, fmt.Errorf("%w: %w", ErrInvalidSchema, err))
+	}
+	return schema, nil
+}
+
+// featureSchema loads the content schema of the feature, nil means the feature has none.
+func featureSchema(ctx context.Context, storage repository.FeatureStorage, featureID int) (*jsonschema.Schema, Error) {
+	feature, err := storage.GetOne(ctx, featureID)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return nil, NewServiceError(false, ErrFeatureNotFound)
+		}
+		if err.IsInternal() {
+			return nil, defaultInternalError
+		}
+		return nil, NewServiceError(true, err.Cause())
+	}
+	if feature.ContentSchema == nil {
+		return nil, nil
+	}
+	schema, cerr := jsonschema.Compile(feature.ContentSchema)
+	if cerr != nil {
+		return nil, NewServiceError(true, fmt.Errorf("stored schema of feature %d is broken: %w", featureID, cerr))
+	}
+	return schema, nil
+}
+
+// checkContent returns ContentError listing every violation of the schema.
+func checkContent(schema *jsonschema.Schema, content models.BannerContent) Error {
+	if schema == nil {
+		return nil
+	}
+	violations, err := schema.Validate(content)
+	if err != nil {
+		return NewServiceError(false, fmt.Errorf("%w: %w", ErrInvalidContent, err))
+	}
+	if len(violations) != 0 {
+		return NewServiceError(false, ContentError{Violations: violations})
+	}
+	return nil
+}
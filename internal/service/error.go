@@ -1,6 +1,11 @@
 package service
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/pkg/jsonschema"
+)
 
 type Error interface {
 	IsInternal() bool
@@ -29,6 +34,61 @@ func NewServiceError(isInternal bool, err error) Error {
 }
 
 var (
-	ErrDefaultInternalError = fmt.Errorf("internal server error, try again later")
-	ErrBannerNotFound       = fmt.Errorf("banner not found")
+	ErrDefaultInternalError  = fmt.Errorf("internal server error, try again later")
+	ErrBannerNotFound        = fmt.Errorf("banner not found")
+	ErrBannerVersionNotFound = fmt.Errorf("banner version not found")
+	ErrVersionRefsMissing    = fmt.Errorf("feature or tags of the version were deleted, the version can't be restored")
+	ErrBannerConflict        = fmt.Errorf("banner for feature and tag already exists")
+	ErrTagForbidden          = fmt.Errorf("user has no access to the tag")
+	ErrInvalidWindow         = fmt.Errorf("field active_from must be earlier than active_until")
+
+	ErrFeatureNotFound      = fmt.Errorf("feature not found")
+	ErrFeatureAlreadyExists = fmt.Errorf("feature with name already exists")
+	ErrFeatureHasBanners    = fmt.Errorf("feature still has banners, delete them first or pass cascade=true")
+	ErrInvalidSchema        = fmt.Errorf("content schema is invalid")
+	ErrInvalidContent       = fmt.Errorf("banner content does not match feature schema")
+
+	ErrTagNotFound      = fmt.Errorf("tag not found")
+	ErrTagAlreadyExists = fmt.Errorf("tag with name already exists")
+	ErrTagInUse         = fmt.Errorf("tag is still assigned to banners or users, unassign it first or pass cascade=true")
+
+	ErrUserNotFound          = fmt.Errorf("user not found")
+	ErrUsernameAlreadyExists = fmt.Errorf("user with name already exists")
+	ErrUnknownTags           = fmt.Errorf("some of tags do not exist")
+	ErrInvalidRefreshToken   = fmt.Errorf("refresh token is invalid or expired")
+	ErrRefreshTokenReused    = fmt.Errorf("refresh token was already used, sign in again")
+	ErrTokenRevoked          = fmt.Errorf("token is revoked")
+	ErrInvalidCredentials    = fmt.Errorf("invalid user name or password")
+	ErrPasswordTooShort      = fmt.Errorf("password is too short")
+	ErrPasswordTooLong       = fmt.Errorf("password is too long")
+	ErrPasswordForbidden     = fmt.Errorf("user can change only their own password")
+	ErrPasswordNotSet        = fmt.Errorf("password is not set yet, ask an admin to set it")
+
+	ErrJobNotFound      = fmt.Errorf("job not found")
+	ErrBannersLocked    = fmt.Errorf("some banners are still locked by other requests, start the deletion again")
+	ErrCacheUnavailable = fmt.Errorf("deleted banners can't be removed from cache and are served until they expire")
 )
+
+type BannerConflictError struct {
+	Conflicts []models.BannerConflict
+}
+
+func (e BannerConflictError) Error() string {
+	return ErrBannerConflict.Error()
+}
+
+func (e BannerConflictError) Unwrap() error {
+	return ErrBannerConflict
+}
+
+type ContentError struct {
+	Violations []jsonschema.Violation
+}
+
+func (e ContentError) Error() string {
+	return ErrInvalidContent.Error()
+}
+
+func (e ContentError) Unwrap() error {
+	return ErrInvalidContent
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/antsrp/banner_service/internal/cache"
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/antsrp/banner_service/pkg/jsonschema"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+type FeatureServicer interface {
+	Get(requests.GetFeaturesRequest) ([]models.Feature, Error)
+	GetOne(requests.GetFeatureRequest) (models.Feature, Error)
+	Create(requests.CreateFeatureRequest) (models.Feature, Error)
+	Update(requests.UpdateFeatureRequest) Error
+	Delete(requests.DeleteFeatureRequest) Error
+	ValidateContent(requests.ValidateContentRequest) ([]jsonschema.Violation, Error)
+}
+
+type FeatureService struct {
+	storage      repository.FeatureStorage
+	cacheStorage cache.Storager[models.Banner]
+	keys         cache.KeyBuilder
+	logger       logger.Logger
+}
+
+func NewFeatureService(storage repository.FeatureStorage, cs cache.Storager[models.Banner], keys cache.KeyBuilder, logger logger.Logger) FeatureService {
+	return FeatureService{
+		storage:      storage,
+		cacheStorage: cs,
+		keys:         keys,
+		logger:       logger,
+	}
+}
+
+func featureError(err repository.DatabaseError) Error {
+	switch {
+	case errors.Is(err.Cause(), repository.ErrEntityNotFound):
+		return NewServiceError(false, ErrFeatureNotFound)
+	case errors.Is(err.Cause(), repository.ErrFeatureAlreadyExists):
+		return NewServiceError(false, ErrFeatureAlreadyExists)
+	case errors.Is(err.Cause(), repository.ErrFeatureHasBanners):
+		return NewServiceError(false, ErrFeatureHasBanners)
+	case err.IsInternal():
+		return defaultInternalError
+	}
+	return NewServiceError(true, err.Cause())
+}
+
+func (s FeatureService) Get(req requests.GetFeaturesRequest) ([]models.Feature, Error) {
+	features, err := s.storage.Get(context.Background(), repository.GetFeaturesLimited{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		return nil, featureError(err)
+	}
+	return features, nil
+}
+func (s FeatureService) GetOne(req requests.GetFeatureRequest) (models.Feature, Error) {
+	feature, err := s.storage.GetOne(context.Background(), req.ID)
+	if err != nil {
+		return models.Feature{}, featureError(err)
+	}
+	return feature, nil
+}
+
+// hasSchema reports whether the client sent a schema, JSON null removes it.
+func hasSchema(data json.RawMessage) bool {
+	return len(data) != 0 && string(data) != "null"
+}
+
+func (s FeatureService) Create(req requests.CreateFeatureRequest) (models.Feature, Error) {
+	var schema json.RawMessage
+	if hasSchema(req.ContentSchema) {
+		if _, err := compileSchema(req.ContentSchema); err != nil {
+			return models.Feature{}, err
+		}
+		schema = req.ContentSchema
+	}
+	feature, err := s.storage.Create(context.Background(), models.Feature{
+		Name:          req.Name,
+		Description:   req.Description,
+		ContentSchema: schema,
+	})
+	if err != nil {
+		return models.Feature{}, featureError(err)
+	}
+	return feature, nil
+}
+func (s FeatureService) Update(req requests.UpdateFeatureRequest) Error {
+	if hasSchema(req.ContentSchema) {
+		if _, err := compileSchema(req.ContentSchema); err != nil {
+			return err
+		}
+	}
+	if err := s.storage.Update(context.Background(), repository.UpdateFeature{
+		ID:            req.ID,
+		Name:          req.Name,
+		Description:   req.Description,
+		ContentSchema: req.ContentSchema,
+	}); err != nil {
+		return featureError(err)
+	}
+	return nil
+}
+func (s FeatureService) Delete(req requests.DeleteFeatureRequest) Error {
+	removed, err := s.storage.Delete(context.Background(), req.ID, req.Cascade)
+	if err != nil {
+		return featureError(err)
+	}
+	evictPairs(s.cacheStorage, s.keys, removed, s.logger)
+	return nil
+}
+func (s FeatureService) ValidateContent(req requests.ValidateContentRequest) ([]jsonschema.Violation, Error) {
+	schema, err := featureSchema(context.Background(), s.storage, req.FeatureID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkContent(schema, req.Content); err != nil {
+		var content ContentError
+		if errors.As(err.Cause(), &content) {
+			return content.Violations, nil
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+var _ FeatureServicer = FeatureService{}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+// jobRetention is how long a finished job can still be asked about.
+const jobRetention = 24 * time.Hour
+
+type JobServicer interface {
+	Get(requests.GetJobRequest) (models.Job, Error)
+}
+
+// JobService keeps background jobs of this instance in memory: other instances don't know them
+// and a restart forgets them, a running job is then lost together with its state.
+type JobService struct {
+	mu     sync.RWMutex
+	jobs   map[string]*models.Job
+	logger logger.Logger
+}
+
+func NewJobService(logger logger.Logger) *JobService {
+	return &JobService{
+		jobs:   make(map[string]*models.Job),
+		logger: logger,
+	}
+}
+
+func (s *JobService) Get(req requests.GetJobRequest) (models.Job, Error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[req.ID]
+	if !ok {
+		return models.Job{}, NewServiceError(false, ErrJobNotFound)
+	}
+	return *job, nil
+}
+
+// start registers a running job and forgets the ones finished long ago.
+func (s *JobService) start(kind string, total int) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("can't generate job id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobRetention {
+			delete(s.jobs, key)
+		}
+	}
+	s.jobs[id] = &models.Job{
+		ID:        id,
+		Kind:      kind,
+		Status:    models.JobStatusRunning,
+		Total:     total,
+		CreatedAt: now,
+	}
+	return id, nil
+}
+
+func (s *JobService) progress(id string, processed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	job.Processed += processed
+	if job.Processed > job.Total {
+		// banners matching the filter may appear while the job runs
+		job.Total = job.Processed
+	}
+}
+
+func (s *JobService) finish(id string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = models.JobStatusDone
+}
+
+var _ JobServicer = &JobService{}
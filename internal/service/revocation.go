@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+const defaultRevocationRefresh = 10 * time.Second
+
+// revocationList keeps revoked tokens in memory, so checking a token doesn't touch the database.
+// Revocations made by this instance apply at once, the ones made by others after the next reload.
+type revocationList struct {
+	storage  repository.UserStorage
+	interval time.Duration
+	// window is how long ago a user must have been revoked to matter, older tokens have expired anyway
+	window time.Duration
+	logger logger.Logger
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	users    map[string]time.Time
+	loadedAt time.Time
+	// loading makes concurrent checks wait for one reload instead of issuing their own
+	loading sync.Mutex
+}
+
+func newRevocationList(storage repository.UserStorage, interval, window time.Duration, logger logger.Logger) *revocationList {
+	if interval <= 0 {
+		interval = defaultRevocationRefresh
+	}
+	return &revocationList{
+		storage:  storage,
+		interval: interval,
+		window:   window,
+		logger:   logger,
+		tokens:   make(map[string]time.Time),
+		users:    make(map[string]time.Time),
+	}
+}
+
+func (l *revocationList) stale() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return time.Since(l.loadedAt) > l.interval
+}
+
+// reload merges the list from the database into the one in memory, keeping the later time
+// of a key: a revocation made here may not be visible to the query yet, and replacing the
+// list would forget it until the next reload. When the database is unavailable
+// the old list stays in use and the next attempt is made after the interval, the hot path
+// shouldn't fail or hammer the database because of revocations.
+func (l *revocationList) reload() {
+	l.loading.Lock()
+	defer l.loading.Unlock()
+	if !l.stale() {
+		return
+	}
+
+	revocations, err := l.storage.Revocations(context.Background(), time.Now().Add(-l.window))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadedAt = time.Now()
+	if err != nil {
+		l.logger.Error("can't load revocations: %v", err.Cause().Error())
+		return
+	}
+	l.prune(time.Now())
+	merge(l.tokens, revocations.Tokens)
+	merge(l.users, revocations.Users)
+}
+
+// merge copies the times from src into dst, a time already in dst is replaced by a later one only.
+func merge(dst, src map[string]time.Time) {
+	for key, at := range src {
+		if current, ok := dst[key]; !ok || at.After(current) {
+			dst[key] = at
+		}
+	}
+}
+
+// prune drops expired tokens and users revoked before the window, the database doesn't return
+// them anymore and merging alone would keep them forever.
+func (l *revocationList) prune(now time.Time) {
+	for jti, expiresAt := range l.tokens {
+		if expiresAt.Before(now) {
+			delete(l.tokens, jti)
+		}
+	}
+	for name, revokedAt := range l.users {
+		if revokedAt.Before(now.Add(-l.window)) {
+			delete(l.users, name)
+		}
+	}
+}
+
+// Revoked reports whether the token with the id, issued to the user at issuedAt, is revoked.
+func (l *revocationList) Revoked(jti, name string, issuedAt time.Time) bool {
+	if l.stale() {
+		l.reload()
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if _, ok := l.tokens[jti]; ok {
+		return true
+	}
+	// iat has a precision of a second, so a token issued within the second of the revocation can't be
+	// told apart from one issued right before it and is rejected too
+	if revokedAt, ok := l.users[name]; ok && !issuedAt.After(revokedAt.Truncate(time.Second)) {
+		return true
+	}
+	return false
+}
+
+func (l *revocationList) addToken(jti string, expiresAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens[jti] = expiresAt
+}
+
+func (l *revocationList) addUser(name string, revokedAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.users[name] = revokedAt
+}
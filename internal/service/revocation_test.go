@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/antsrp/banner_service/pkg/logger/slog"
+)
+
+type fakeDatabaseError struct {
+	err error
+}
+
+func (e fakeDatabaseError) IsInternal() bool { return false }
+func (e fakeDatabaseError) Cause() error     { return e.err }
+
+// revocationStorage answers Revocations only, the list needs nothing else from the storage.
+type revocationStorage struct {
+	repository.UserStorage
+	revocations repository.Revocations
+	err         error
+	calls       int
+}
+
+func (s *revocationStorage) Revocations(context.Context, time.Time) (repository.Revocations, repository.DatabaseError) {
+	s.calls++
+	if s.err != nil {
+		return repository.Revocations{}, fakeDatabaseError{s.err}
+	}
+	return s.revocations, nil
+}
+
+func TestRevocationListRevoked(t *testing.T) {
+	revokedAt := time.Date(2024, 4, 1, 12, 0, 0, 500_000_000, time.UTC)
+	storage := &revocationStorage{revocations: repository.Revocations{
+		Tokens: map[string]time.Time{"revoked-jti": revokedAt.Add(time.Hour)},
+		Users:  map[string]time.Time{"user": revokedAt},
+	}}
+	list := newRevocationList(storage, time.Hour, 24*time.Hour, slog.NewTextLogger(io.Discard))
+
+	tests := []struct {
+		name     string
+		jti      string
+		user     string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "revoked token", jti: "revoked-jti", user: "other", issuedAt: revokedAt.Add(time.Minute), want: true},
+		{name: "token of another user", jti: "jti", user: "other", issuedAt: revokedAt.Add(-time.Minute)},
+		{name: "issued before the revocation", jti: "jti", user: "user", issuedAt: revokedAt.Add(-time.Minute), want: true},
+		{name: "issued in the previous second", jti: "jti", user: "user", issuedAt: revokedAt.Truncate(time.Second).Add(-time.Second), want: true},
+		{name: "issued within the second of the revocation", jti: "jti", user: "user", issuedAt: revokedAt.Truncate(time.Second), want: true},
+		{name: "issued after the revocation", jti: "jti", user: "user", issuedAt: revokedAt.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := list.Revoked(tt.jti, tt.user, tt.issuedAt); got != tt.want {
+				t.Errorf("Revoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if storage.calls != 1 {
+		t.Errorf("Revocations() called %d times, want 1 within the interval", storage.calls)
+	}
+}
+
+func TestRevocationListLocalChanges(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		apply func(l *revocationList)
+		jti   string
+		user  string
+		want  bool
+	}{
+		{name: "nothing revoked", apply: func(*revocationList) {}, jti: "jti", user: "user"},
+		{name: "token added", apply: func(l *revocationList) { l.addToken("jti", now.Add(time.Hour)) }, jti: "jti", user: "user", want: true},
+		{name: "user added", apply: func(l *revocationList) { l.addUser("user", now.Add(time.Second)) }, jti: "jti", user: "user", want: true},
+		{name: "another user added", apply: func(l *revocationList) { l.addUser("other", now.Add(time.Second)) }, jti: "jti", user: "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &revocationStorage{revocations: repository.Revocations{
+				Tokens: map[string]time.Time{},
+				Users:  map[string]time.Time{},
+			}}
+			list := newRevocationList(storage, time.Hour, 24*time.Hour, slog.NewTextLogger(io.Discard))
+			list.Revoked("", "", now) // loads the list, so later checks don't reload it
+			tt.apply(list)
+			if got := list.Revoked(tt.jti, tt.user, now.Add(-time.Minute)); got != tt.want {
+				t.Errorf("Revoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevocationListReloadMerges(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		local    time.Time
+		stored   map[string]time.Time
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "local revocation not stored yet", local: now, stored: map[string]time.Time{}, issuedAt: now.Add(-time.Minute), want: true},
+		{name: "local revocation is later", local: now, stored: map[string]time.Time{"user": now.Add(-time.Hour)}, issuedAt: now.Add(-time.Minute), want: true},
+		{name: "stored revocation is later", local: now.Add(-time.Hour), stored: map[string]time.Time{"user": now}, issuedAt: now.Add(-time.Minute), want: true},
+		{name: "issued after both", local: now.Add(-time.Hour), stored: map[string]time.Time{"user": now.Add(-time.Minute)}, issuedAt: now.Add(time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &revocationStorage{revocations: repository.Revocations{
+				Tokens: map[string]time.Time{},
+				Users:  map[string]time.Time{},
+			}}
+			list := newRevocationList(storage, time.Millisecond, 24*time.Hour, slog.NewTextLogger(io.Discard))
+			list.Revoked("", "", now)
+			list.addUser("user", tt.local)
+
+			storage.revocations = repository.Revocations{Tokens: map[string]time.Time{}, Users: tt.stored}
+			time.Sleep(5 * time.Millisecond)
+			if got := list.Revoked("jti", "user", tt.issuedAt); got != tt.want {
+				t.Errorf("Revoked() = %v, want %v", got, tt.want)
+			}
+			if storage.calls != 2 {
+				t.Errorf("Revocations() called %d times, want 2", storage.calls)
+			}
+		})
+	}
+}
+
+func TestRevocationListReloadFailure(t *testing.T) {
+	storage := &revocationStorage{revocations: repository.Revocations{
+		Tokens: map[string]time.Time{"jti": time.Now().Add(time.Hour)},
+		Users:  map[string]time.Time{},
+	}}
+	list := newRevocationList(storage, time.Millisecond, 24*time.Hour, slog.NewTextLogger(io.Discard))
+	if !list.Revoked("jti", "user", time.Now()) {
+		t.Fatal("Revoked() = false after the first load, want true")
+	}
+
+	storage.err = errors.New("database is unavailable")
+	time.Sleep(5 * time.Millisecond)
+	if !list.Revoked("jti", "user", time.Now()) {
+		t.Error("Revoked() = false after a failed reload, want the old list to stay in use")
+	}
+	if storage.calls != 2 {
+		t.Errorf("Revocations() called %d times, want 2", storage.calls)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/antsrp/banner_service/internal/cache"
+	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
+	"github.com/antsrp/banner_service/internal/repository"
+	"github.com/antsrp/banner_service/pkg/logger"
+)
+
+type TagServicer interface {
+	Get(requests.GetTagsRequest) ([]models.Tag, Error)
+	GetOne(requests.GetTagRequest) (models.Tag, Error)
+	Create(requests.CreateTagRequest) (models.Tag, Error)
+	Update(requests.UpdateTagRequest) Error
+	Delete(requests.DeleteTagRequest, string) Error
+}
+
+type TagService struct {
+	storage      repository.TagStorage
+	cacheStorage cache.Storager[models.Banner]
+	keys         cache.KeyBuilder
+	logger       logger.Logger
+}
+
+func NewTagService(storage repository.TagStorage, cs cache.Storager[models.Banner], keys cache.KeyBuilder, logger logger.Logger) TagService {
+	return TagService{
+		storage:      storage,
+		cacheStorage: cs,
+		keys:         keys,
+		logger:       logger,
+	}
+}
+
+func tagError(err repository.DatabaseError) Error {
+	switch {
+	case errors.Is(err.Cause(), repository.ErrEntityNotFound):
+		return NewServiceError(false, ErrTagNotFound)
+	case errors.Is(err.Cause(), repository.ErrTagAlreadyExists):
+		return NewServiceError(false, ErrTagAlreadyExists)
+	case errors.Is(err.Cause(), repository.ErrTagInUse):
+		return NewServiceError(false, ErrTagInUse)
+	case err.IsInternal():
+		return defaultInternalError
+	}
+	return NewServiceError(true, err.Cause())
+}
+
+func (s TagService) Get(req requests.GetTagsRequest) ([]models.Tag, Error) {
+	tags, err := s.storage.Get(context.Background(), repository.GetTagsLimited{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		return nil, tagError(err)
+	}
+	return tags, nil
+}
+func (s TagService) GetOne(req requests.GetTagRequest) (models.Tag, Error) {
+	tag, err := s.storage.GetOne(context.Background(), req.ID)
+	if err != nil {
+		return models.Tag{}, tagError(err)
+	}
+	return tag, nil
+}
+func (s TagService) Create(req requests.CreateTagRequest) (models.Tag, Error) {
+	tag, err := s.storage.Create(context.Background(), models.Tag{Name: req.Name})
+	if err != nil {
+		return models.Tag{}, tagError(err)
+	}
+	return tag, nil
+}
+func (s TagService) Update(req requests.UpdateTagRequest) Error {
+	if err := s.storage.Rename(context.Background(), req.ID, req.Name); err != nil {
+		return tagError(err)
+	}
+	return nil
+}
+func (s TagService) Delete(req requests.DeleteTagRequest, author string) Error {
+	removed, err := s.storage.Delete(context.Background(), req.ID, req.Cascade, author)
+	if err != nil {
+		return tagError(err)
+	}
+	evictPairs(s.cacheStorage, s.keys, removed, s.logger)
+	return nil
+}
+
+var _ TagServicer = TagService{}
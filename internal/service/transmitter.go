@@ -1,44 +1,90 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"sync"
 	"time"
 
 	"github.com/antsrp/banner_service/internal/cache"
 	"github.com/antsrp/banner_service/internal/domain/models"
-	"github.com/antsrp/banner_service/internal/domain/models/requests"
 	"github.com/antsrp/banner_service/pkg/logger"
 )
 
+const (
+	// refreshOverlap covers transactions that commit after a refresh with timestamps before it
+	refreshOverlap = time.Minute
+	// tombstoneRetention is how long removals are kept, a transmitter lagging more does a full resync
+	tombstoneRetention = time.Hour
+)
+
+const defaultRefreshInterval = 270 * time.Second
+
 type Transmitter interface {
 	Start()
 	Stop()
+	// Refresh asks for a refresh right away, full reloads the whole cache
+	Refresh(full bool)
+	Status() models.CacheStatus
 }
 
 type TransmitService struct {
 	bannerService BannerServicer
 	cacheStorage  cache.Storager[models.Banner]
+	keys          cache.KeyBuilder
 	logger        logger.Logger
+	interval      time.Duration
+	jitter        time.Duration
+	status        *refreshStatus
 	end           chan struct{}
+	trigger       chan struct{}
+	resync        chan struct{}
 }
 
-func NewTransmitService(bs BannerServicer, cs cache.Storager[models.Banner], logger logger.Logger, end chan struct{}) TransmitService {
+type refreshStatus struct {
+	mu     sync.Mutex
+	status models.CacheStatus
+}
+
+// NewTransmitService creates a transmitter refreshing the cache every interval plus a random part of jitter,
+// so instances started together don't hit the database at the same moment.
+func NewTransmitService(bs BannerServicer, cs cache.Storager[models.Banner], keys cache.KeyBuilder, interval, jitter time.Duration, logger logger.Logger, end chan struct{}) TransmitService {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	if jitter < 0 {
+		jitter = 0
+	}
 	return TransmitService{
 		bannerService: bs,
 		cacheStorage:  cs,
+		keys:          keys,
 		logger:        logger,
-		end:           end,
+		interval:      interval,
+		jitter:        jitter,
+		status: &refreshStatus{status: models.CacheStatus{
+			Interval: interval.String(),
+			Jitter:   jitter.String(),
+		}},
+		end:     end,
+		trigger: make(chan struct{}, 1),
+		resync:  make(chan struct{}, 1),
 	}
 }
 
 func (s TransmitService) Start() {
-	s.getAll()
+	since := s.refresh(time.Time{})
 	for {
 		select {
 		case <-s.end:
 			return
-		case <-time.After(270 * time.Second):
-			s.getAll()
+		case <-s.resync:
+			since = s.refresh(time.Time{})
+		case <-s.trigger:
+			since = s.refresh(since)
+		case <-time.After(s.nextRefresh()):
+			since = s.refresh(since)
 		}
 	}
 }
@@ -47,26 +93,144 @@ func (s TransmitService) Stop() {
 	s.end <- struct{}{}
 }
 
-func (s TransmitService) getAll() {
-	banners, err := s.bannerService.Get(requests.GetBannersRequest{})
+func (s TransmitService) nextRefresh() time.Duration {
+	if s.jitter == 0 {
+		return s.interval
+	}
+	return s.interval + rand.N(s.jitter)
+}
+
+func (s TransmitService) Refresh(full bool) {
+	ch := s.trigger
+	if full {
+		ch = s.resync
+	}
+	select {
+	case ch <- struct{}{}:
+	default: // already requested
+	}
+}
+
+func (s TransmitService) Status() models.CacheStatus {
+	s.status.mu.Lock()
+	defer s.status.mu.Unlock()
+	status := s.status.status
+	if status.LastRefresh != nil {
+		last := *status.LastRefresh
+		status.LastRefresh = &last
+	}
+	return status
+}
+
+func (s TransmitService) report(refresh models.CacheRefresh, err error) {
+	refresh.DurationMS = time.Since(refresh.StartedAt).Milliseconds()
+	s.status.mu.Lock()
+	defer s.status.mu.Unlock()
 	if err != nil {
-		s.logger.Info("can't get banners to put them into cache: %v", err.Cause().Error())
+		refresh.Error = err.Error()
+		s.status.status.LastError = refresh.Error
+		s.status.status.LastErrorAt = &refresh.StartedAt
+	} else {
+		s.status.status.LastSuccessAt = &refresh.StartedAt
+	}
+	s.status.status.LastRefresh = &refresh
+}
+
+// refresh writes banners changed after since into the cache and returns the mark for the next call.
+// Zero since reloads everything. The mark is kept as it was when something fails, so nothing is skipped.
+func (s TransmitService) refresh(since time.Time) time.Time {
+	from := since
+	if !since.IsZero() {
+		from = since.Add(-refreshOverlap)
+		if time.Since(from) > tombstoneRetention {
+			s.logger.Info("cache refresh is too far behind, reloading everything")
+			from = time.Time{}
+		}
 	}
-	if err := s.writeToCache(banners); err != nil {
+	refresh := models.CacheRefresh{StartedAt: time.Now(), Full: from.IsZero()}
+
+	changes, serr := s.bannerService.Changes(from)
+	if serr != nil {
+		s.logger.Info("can't get banners to put them into cache: %v", serr.Cause().Error())
+		s.report(refresh, fmt.Errorf("can't get banners: %w", serr.Cause()))
+		return since
+	}
+	var err error
+	if rebuilder, ok := s.cacheStorage.(cache.Rebuilder[models.Banner]); ok && from.IsZero() {
+		refresh.KeysWritten, refresh.KeysDeleted, changes.Until, err = s.rebuildCache(rebuilder, changes)
+	} else {
+		refresh.KeysWritten, refresh.KeysDeleted, err = s.writeToCache(changes)
+	}
+	if err != nil {
 		s.logger.Info("can't write banners into cache: %v", err.Error())
+		s.report(refresh, err)
+		return since
 	}
+	s.report(refresh, nil)
+	return changes.Until
 }
 
-func (s TransmitService) writeToCache(banners []models.Banner) error {
-	for _, banner := range banners {
+// rebuildCache replaces the whole cache with live banners, so readers never see a half-written reload.
+// Changes saved after the snapshot was read were written into the previous generation, by this instance
+// or by others, so they are read again and replayed into the new one right after the switch.
+// It returns the number of keys written and deleted and the mark for the next refresh.
+func (s TransmitService) rebuildCache(rebuilder cache.Rebuilder[models.Banner], changes models.BannerChanges) (int, int, time.Time, error) {
+	values := make(map[string]models.Banner)
+	for _, banner := range changes.Changed {
+		if banner.Status(changes.Until) != models.BannerStatusLive {
+			continue
+		}
 		for _, tag := range banner.TagIDS {
-			key := fmt.Sprintf("(%d, %d)", banner.FeatureID, tag)
-			if err := s.cacheStorage.Set(key, banner); err != nil {
-				return fmt.Errorf("can't put banner into cache: %v", err.Error())
+			values[s.keys.Banner(banner.FeatureID, tag)] = banner
+		}
+	}
+	if err := rebuilder.Rebuild(context.Background(), values); err != nil {
+		return 0, 0, time.Time{}, err
+	}
+
+	replay, serr := s.bannerService.Changes(changes.Until.Add(-refreshOverlap))
+	if serr != nil {
+		return len(values), 0, time.Time{}, fmt.Errorf("can't get banners changed during the rebuild: %w", serr.Cause())
+	}
+	written, deleted, err := s.writeToCache(replay)
+	if err != nil {
+		return len(values) + written, deleted, time.Time{}, err
+	}
+	return len(values) + written, deleted, replay.Until, nil
+}
+
+// writeToCache returns the number of keys written and deleted.
+func (s TransmitService) writeToCache(changes models.BannerChanges) (int, int, error) {
+	evict := make([]string, 0, len(changes.Removed))
+	for _, key := range changes.Removed {
+		evict = append(evict, s.keys.Banner(key.FeatureID, key.TagID))
+	}
+	set := make(map[string]models.Banner)
+	for _, banner := range changes.Changed {
+		live := banner.Status(changes.Until) == models.BannerStatusLive
+		for _, tag := range banner.TagIDS {
+			key := s.keys.Banner(banner.FeatureID, tag)
+			if live {
+				set[key] = banner
+			} else {
+				evict = append(evict, key)
 			}
 		}
 	}
-	return nil
+
+	ctx := context.Background()
+	// removals go first, the pair may already belong to a banner changed later
+	if len(evict) != 0 {
+		if err := s.cacheStorage.DeleteMany(ctx, evict); err != nil {
+			return 0, 0, fmt.Errorf("can't delete banners from cache: %v", err.Error())
+		}
+	}
+	if len(set) != 0 {
+		if err := s.cacheStorage.MSet(ctx, set); err != nil {
+			return 0, len(evict), fmt.Errorf("can't put banners into cache: %v", err.Error())
+		}
+	}
+	return len(set), len(evict), nil
 }
 
 var _ Transmitter = TransmitService{}
@@ -2,65 +2,205 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/antsrp/banner_service/internal/domain/models"
+	"github.com/antsrp/banner_service/internal/domain/models/requests"
 	"github.com/antsrp/banner_service/internal/repository"
 	"github.com/antsrp/banner_service/pkg/jwt"
 	"github.com/antsrp/banner_service/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserStorager interface {
 	UserByToken(string) (models.User, Error)
 	GenerateToken(models.User) (string, Error)
-	SignIn(string) (string, Error)
+	SignIn(requests.SignInRequest) (models.Tokens, Error)
+	Refresh(string) (models.Tokens, Error)
+	SignOut(requests.SignOutRequest) Error
+	// JWKS returns the public keys tokens can be verified with
+	JWKS() jwt.JWKSet
 }
 
+type UserServicer interface {
+	UserStorager
+	Create(requests.CreateUserRequest) Error
+	Get(requests.GetUsersRequest) ([]models.User, Error)
+	Update(requests.UpdateUserRequest) Error
+	AddTags(requests.UserTagsRequest) Error
+	RemoveTags(requests.UserTagsRequest) Error
+	Delete(requests.DeleteUserRequest) Error
+	Revoke(requests.RevokeUserRequest) Error
+	SetPassword(req requests.SetPasswordRequest, actor models.User) Error
+}
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+const (
+	minPasswordLength = 8
+	// maxPasswordLength is the limit of bcrypt, longer passwords would be silently truncated
+	maxPasswordLength = 72
+	// dummyPasswordHash is compared against when the user doesn't exist or has no password,
+	// so a failed sign in takes the same time whatever the reason
+	dummyPasswordHash = "$2a$10$8RXS.y63cE751ZW6NW1AWuexBza5vNgX22Rmtz7jKytrFWZFmEYl."
+)
+
 type UserService struct {
 	userStorage repository.UserStorage
 	jwtService  jwt.Service
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	revocations *revocationList
 	logger      logger.Logger
 }
 
-func NewUserService(us repository.UserStorage, js jwt.Service, logger logger.Logger) UserService {
+// NewUserService creates a user service issuing tokens for accessTTL and refreshTTL.
+// Revocations made by other instances are picked up every revocationRefresh.
+func NewUserService(us repository.UserStorage, js jwt.Service, accessTTL, refreshTTL, revocationRefresh time.Duration, logger logger.Logger) UserService {
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
 	return UserService{
 		userStorage: us,
 		jwtService:  js,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		// twice the token lifetime leaves room for the clock skew allowed by the jwt service
+		revocations: newRevocationList(us, revocationRefresh, 2*accessTTL, logger),
 		logger:      logger,
 	}
 }
 
-func (s UserService) UserByToken(token string) (models.User, Error) {
+type tokenClaims struct {
+	user      models.User
+	jti       string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+func (s UserService) parseToken(token string) (tokenClaims, Error) {
 	data, err := s.jwtService.Parse(token)
 	if err != nil {
 		s.logger.Info(fmt.Errorf("error while parsing token: %w", err).Error())
-		return models.User{}, NewServiceError(false, jwt.ErrInvalidToken)
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return tokenClaims{}, NewServiceError(false, jwt.ErrTokenExpired)
+		}
+		return tokenClaims{}, NewServiceError(false, jwt.ErrInvalidToken)
 	}
-	var user models.User
+	var claims tokenClaims
 
 	if name, ok := data[`username`].(string); ok {
-		user.Name = name
+		claims.user.Name = name
 	} else {
-		return models.User{}, NewServiceError(false, fmt.Errorf("bad token"))
+		return tokenClaims{}, NewServiceError(false, fmt.Errorf("bad token"))
 	}
 
 	if isAdmin, ok := data[`is_admin`].(bool); ok {
-		user.IsAdmin = isAdmin
+		claims.user.IsAdmin = isAdmin
+	} else {
+		return tokenClaims{}, NewServiceError(false, fmt.Errorf("bad token"))
+	}
+
+	// tags are checked on every /user_banner request, carrying them saves a database query
+	if tags, ok := data[`tags`].([]any); ok {
+		for _, tag := range tags {
+			id, ok := tag.(float64)
+			if !ok {
+				return tokenClaims{}, NewServiceError(false, fmt.Errorf("bad token"))
+			}
+			claims.user.Tags = append(claims.user.Tags, int(id))
+		}
+	}
+
+	if jti, ok := data[`jti`].(string); ok {
+		claims.jti = jti
 	} else {
-		return models.User{}, NewServiceError(false, fmt.Errorf("bad token"))
+		return tokenClaims{}, NewServiceError(false, fmt.Errorf("bad token"))
 	}
 
-	return user, nil
+	// numbers in claims are decoded as float64
+	iat, iok := data[`iat`].(float64)
+	exp, eok := data[`exp`].(float64)
+	if !iok || !eok {
+		return tokenClaims{}, NewServiceError(false, fmt.Errorf("bad token"))
+	}
+	claims.issuedAt = time.Unix(int64(iat), 0)
+	claims.expiresAt = time.Unix(int64(exp), 0)
+
+	return claims, nil
 }
 
-func (s UserService) GenerateToken(user models.User) (string, Error) {
+func (s UserService) UserByToken(token string) (models.User, Error) {
+	claims, err := s.parseToken(token)
+	if err != nil {
+		return models.User{}, err
+	}
+	if s.revocations.Revoked(claims.jti, claims.user.Name, claims.issuedAt) {
+		return models.User{}, NewServiceError(false, ErrTokenRevoked)
+	}
+
+	return claims.user, nil
+}
+
+// accessToken returns a signed token for the user together with its expiration time.
+func (s UserService) accessToken(user models.User) (string, time.Time, error) {
+	jti, err := randomString()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.accessTTL)
 	token, err := s.jwtService.NewToken(map[string]any{
-		`is_admin`:   user.IsAdmin,
-		`username`:   user.Name,
-		`created_at`: time.Now().Unix(),
+		`is_admin`: user.IsAdmin,
+		`username`: user.Name,
+		`tags`:     user.Tags,
+		`jti`:      jti,
+		`iat`:      now.Unix(),
+		`nbf`:      now.Unix(),
+		`exp`:      expiresAt.Unix(),
 	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// newRefreshToken returns a random opaque token and the hash it is stored by.
+func newRefreshToken() (string, string, error) {
+	token, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s UserService) GenerateToken(user models.User) (string, Error) {
+	token, _, err := s.accessToken(user)
 	if err != nil {
 		s.logger.Info(fmt.Errorf("can't create token: %w", err).Error())
 		return "", defaultInternalError
@@ -69,37 +209,298 @@ func (s UserService) GenerateToken(user models.User) (string, Error) {
 	return token, nil
 }
 
-func (s UserService) SignIn(name string) (string, Error) {
+// issueTokens signs an access token for the user and records it as the last one issued.
+func (s UserService) issueTokens(ctx context.Context, user repository.UserWithToken, refreshToken string) (models.Tokens, Error) {
+	token, expiresAt, err := s.accessToken(user.User)
+	if err != nil {
+		s.logger.Error(fmt.Errorf("can't create token: %w", err).Error())
+		return models.Tokens{}, defaultInternalError
+	}
+	user.Token = token
+	if err := s.userStorage.AddToken(ctx, user); err != nil {
+		s.logger.Error(fmt.Errorf("can't add token to storage: %w", err.Cause()).Error())
+		if err.IsInternal() {
+			return models.Tokens{}, defaultInternalError
+		}
+		return models.Tokens{}, NewServiceError(false, err.Cause())
+	}
+
+	return models.Tokens{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// SignIn checks the credentials. Every reason of a failure, be it unknown user, user without a password
+// or a wrong password, gives the same error.
+func (s UserService) SignIn(req requests.SignInRequest) (models.Tokens, Error) {
 	ctx := context.Background()
-	user, err := s.userStorage.FindByName(ctx, name)
+	user, err := s.userStorage.FindByName(ctx, req.Name)
+	if err != nil && !errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+		s.logger.Error(fmt.Errorf("can't find user: %w", err.Cause()).Error())
+		return models.Tokens{}, defaultInternalError
+	}
+	hash := user.PasswordHash
+	if hash == "" {
+		hash = dummyPasswordHash
+	}
+	if cerr := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)); cerr != nil || user.PasswordHash == "" {
+		s.logger.Info("failed sign in attempt for user %s", req.Name)
+		return models.Tokens{}, NewServiceError(false, ErrInvalidCredentials)
+	}
+
+	refreshToken, hash, rerr := newRefreshToken()
+	if rerr != nil {
+		s.logger.Error(fmt.Errorf("can't create refresh token: %w", rerr).Error())
+		return models.Tokens{}, defaultInternalError
+	}
+	family, rerr := randomString()
+	if rerr != nil {
+		s.logger.Error(fmt.Errorf("can't create refresh token family: %w", rerr).Error())
+		return models.Tokens{}, defaultInternalError
+	}
+	if err := s.userStorage.AddRefreshToken(ctx, repository.RefreshToken{
+		UserID:    user.ID,
+		Family:    family,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}); err != nil {
+		s.logger.Error(fmt.Errorf("can't add refresh token to storage: %w", err.Cause()).Error())
+		return models.Tokens{}, defaultInternalError
+	}
+
+	return s.issueTokens(ctx, user, refreshToken)
+}
+
+// Refresh exchanges the refresh token for a new pair. Every refresh token is accepted once,
+// a second exchange means it leaked, and all tokens descending from the same sign in are revoked.
+func (s UserService) Refresh(refreshToken string) (models.Tokens, Error) {
+	ctx := context.Background()
+	next, hash, rerr := newRefreshToken()
+	if rerr != nil {
+		s.logger.Error(fmt.Errorf("can't create refresh token: %w", rerr).Error())
+		return models.Tokens{}, defaultInternalError
+	}
+
+	user, err := s.userStorage.RotateRefreshToken(ctx, hashRefreshToken(refreshToken), repository.RefreshToken{
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
 	if err != nil {
-		s.logger.Info(fmt.Errorf("can't find user: %w", err.Cause()).Error())
-		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
-			return "", NewServiceError(false, fmt.Errorf("user not found"))
+		switch {
+		case errors.Is(err.Cause(), repository.ErrRefreshTokenReused):
+			s.logger.Warn("refresh token reuse detected, token family is revoked")
+			return models.Tokens{}, NewServiceError(false, ErrRefreshTokenReused)
+		case errors.Is(err.Cause(), repository.ErrEntityNotFound), errors.Is(err.Cause(), repository.ErrRefreshTokenExpired):
+			s.logger.Info(fmt.Errorf("can't refresh token: %w", err.Cause()).Error())
+			return models.Tokens{}, NewServiceError(false, ErrInvalidRefreshToken)
 		}
-		return "", defaultInternalError
+		s.logger.Error(fmt.Errorf("can't refresh token: %w", err.Cause()).Error())
+		return models.Tokens{}, defaultInternalError
 	}
-	if user.Token == "" {
-		token, err := s.jwtService.NewToken(map[string]any{
-			`is_admin`:   user.IsAdmin,
-			`username`:   user.Name,
-			`created_at`: time.Now().Unix(),
-		})
+
+	return s.issueTokens(ctx, user, next)
+}
+
+// SignOut revokes the access token and, if given, the refresh token family of the same sign in.
+func (s UserService) SignOut(req requests.SignOutRequest) Error {
+	ctx := context.Background()
+	claims, serr := s.parseToken(req.Token)
+	if serr != nil {
+		return serr
+	}
+
+	if err := s.userStorage.RevokeToken(ctx, repository.RevokedToken{
+		JTI:       claims.jti,
+		Token:     req.Token,
+		ExpiresAt: claims.expiresAt,
+	}); err != nil {
+		s.logger.Error(fmt.Errorf("can't revoke token: %w", err.Cause()).Error())
+		return defaultInternalError
+	}
+	s.revocations.addToken(claims.jti, claims.expiresAt)
+
+	if req.RefreshToken != "" {
+		if err := s.userStorage.RevokeRefreshToken(ctx, claims.user.Name, hashRefreshToken(req.RefreshToken)); err != nil {
+			s.logger.Error(fmt.Errorf("can't revoke refresh token: %w", err.Cause()).Error())
+			return defaultInternalError
+		}
+	}
+	return nil
+}
+
+func userError(err repository.DatabaseError) Error {
+	switch {
+	case errors.Is(err.Cause(), repository.ErrEntityNotFound):
+		return NewServiceError(false, ErrUserNotFound)
+	case errors.Is(err.Cause(), repository.ErrUsernameAlreadyExists):
+		return NewServiceError(false, ErrUsernameAlreadyExists)
+	case errors.Is(err.Cause(), repository.ErrUnknownTags):
+		return NewServiceError(false, ErrUnknownTags)
+	case err.IsInternal():
+		return defaultInternalError
+	}
+	return NewServiceError(true, err.Cause())
+}
+
+func (s UserService) Create(req requests.CreateUserRequest) Error {
+	if err := s.userStorage.Create(context.Background(), models.User{
+		Name:    req.Name,
+		IsAdmin: req.IsAdmin,
+		Tags:    req.Tags,
+	}); err != nil {
+		return userError(err)
+	}
+	return nil
+}
+
+func (s UserService) Get(req requests.GetUsersRequest) ([]models.User, Error) {
+	users, err := s.userStorage.Get(context.Background(), repository.GetUsersLimited{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		return nil, userError(err)
+	}
+	return users, nil
+}
+
+func (s UserService) Update(req requests.UpdateUserRequest) Error {
+	if req.IsAdmin == nil {
+		return nil
+	}
+	revokedAt, err := s.userStorage.SetAdmin(context.Background(), req.Name, *req.IsAdmin)
+	if err != nil {
+		return userError(err)
+	}
+	if !revokedAt.IsZero() {
+		s.revocations.addUser(req.Name, revokedAt)
+	}
+	return nil
+}
+
+func (s UserService) AddTags(req requests.UserTagsRequest) Error {
+	revokedAt, err := s.userStorage.AddTags(context.Background(), req.Name, req.Tags)
+	if err != nil {
+		return userError(err)
+	}
+	s.revocations.addUser(req.Name, revokedAt)
+	return nil
+}
+
+func (s UserService) RemoveTags(req requests.UserTagsRequest) Error {
+	revokedAt, err := s.userStorage.RemoveTags(context.Background(), req.Name, req.Tags)
+	if err != nil {
+		return userError(err)
+	}
+	s.revocations.addUser(req.Name, revokedAt)
+	return nil
+}
+
+func (s UserService) Delete(req requests.DeleteUserRequest) Error {
+	revokedAt, err := s.userStorage.Delete(context.Background(), req.Name)
+	if err != nil {
+		return userError(err)
+	}
+	s.revocations.addUser(req.Name, revokedAt)
+	return nil
+}
+
+// SetPassword sets the password of the user. Admins can set anyone's password, other users
+// only their own and only knowing the current one, so the first password is always set by an admin.
+// All tokens issued before are revoked.
+func (s UserService) SetPassword(req requests.SetPasswordRequest, actor models.User) Error {
+	switch {
+	case len(req.Password) < minPasswordLength:
+		return NewServiceError(false, ErrPasswordTooShort)
+	case len(req.Password) > maxPasswordLength:
+		return NewServiceError(false, ErrPasswordTooLong)
+	}
+
+	ctx := context.Background()
+	if !actor.IsAdmin {
+		if actor.Name != req.Name {
+			return NewServiceError(false, ErrPasswordForbidden)
+		}
+		user, err := s.userStorage.FindByName(ctx, req.Name)
 		if err != nil {
-			s.logger.Error(fmt.Errorf("can't create token: %w", err).Error())
-			return "", defaultInternalError
+			return userError(err)
+		}
+		// anyone holding a token of the user could claim an account without a password otherwise
+		if user.PasswordHash == "" {
+			return NewServiceError(false, ErrPasswordNotSet)
+		}
+		if cerr := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); cerr != nil {
+			return NewServiceError(false, ErrInvalidCredentials)
 		}
-		user.Token = token
 	}
-	if err := s.userStorage.AddToken(ctx, user); err != nil {
-		s.logger.Error(fmt.Errorf("can't add token to storage: %w", err.Cause()).Error())
-		if err.IsInternal() {
-			return "", defaultInternalError
+
+	hash, herr := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if herr != nil {
+		s.logger.Error(fmt.Errorf("can't hash password: %w", herr).Error())
+		return defaultInternalError
+	}
+	if err := s.userStorage.SetPassword(ctx, req.Name, string(hash)); err != nil {
+		return userError(err)
+	}
+	return s.Revoke(requests.RevokeUserRequest{Name: req.Name})
+}
+
+// Bootstrap makes sure the admin with the name exists and can sign in with the password. Only a missing
+// password is set, so a password changed later through the api survives restarts.
+func (s UserService) Bootstrap(name, password string) Error {
+	switch {
+	case len(password) < minPasswordLength:
+		return NewServiceError(false, ErrPasswordTooShort)
+	case len(password) > maxPasswordLength:
+		return NewServiceError(false, ErrPasswordTooLong)
+	}
+
+	ctx := context.Background()
+	user, err := s.userStorage.FindByName(ctx, name)
+	if err != nil {
+		if !errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return userError(err)
+		}
+		if err := s.userStorage.Create(ctx, models.User{Name: name, IsAdmin: true}); err != nil {
+			return userError(err)
 		}
-		return "", NewServiceError(false, err.Cause())
+		s.logger.Info("admin %s created", name)
+	} else if !user.IsAdmin {
+		if _, err := s.userStorage.SetAdmin(ctx, name, true); err != nil {
+			return userError(err)
+		}
+		s.logger.Info("user %s made an admin", name)
+	}
+	if user.PasswordHash != "" {
+		return nil
 	}
 
-	return user.Token, nil
+	hash, herr := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if herr != nil {
+		s.logger.Error(fmt.Errorf("can't hash password: %w", herr).Error())
+		return defaultInternalError
+	}
+	if err := s.userStorage.SetPassword(ctx, name, string(hash)); err != nil {
+		return userError(err)
+	}
+	s.logger.Info("password of admin %s set", name)
+	return nil
+}
+
+func (s UserService) JWKS() jwt.JWKSet {
+	return s.jwtService.JWKS()
+}
+
+// Revoke rejects every access and refresh token issued to the user so far.
+func (s UserService) Revoke(req requests.RevokeUserRequest) Error {
+	revokedAt, err := s.userStorage.RevokeUser(context.Background(), req.Name)
+	if err != nil {
+		return userError(err)
+	}
+	s.revocations.addUser(req.Name, revokedAt)
+	return nil
 }
 
-var _ UserStorager = UserService{}
+var _ UserServicer = UserService{}
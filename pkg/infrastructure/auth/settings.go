@@ -0,0 +1,23 @@
+package auth
+
+// Settings durations are in seconds
+type Settings struct {
+	// PrivateKeyFile is a PEM encoded RSA, P-256 or Ed25519 key, tokens are signed with HMAC and .secret without it
+	PrivateKeyFile string `envconfig:"PRIVATE_KEY_FILE"`
+	// SigningKeysDir holds a keyring: <id>.pem and <id>.secret keys and the file active with the id of the signing one.
+	// Without it the keyring is read from SIGNING_KEY_<ID> variables and ACTIVE_SIGNING_KEY, when the latter is set,
+	// such a keyring changes only with a restart.
+	SigningKeysDir   string `envconfig:"SIGNING_KEYS_DIR"`
+	ActiveSigningKey string `envconfig:"ACTIVE_SIGNING_KEY"`
+	// SigningKeysReload is how often the keyring dir is reloaded, 0 means only on SIGHUP
+	SigningKeysReload int `envconfig:"SIGNING_KEYS_RELOAD"`
+	AccessTokenTTL    int `envconfig:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL   int `envconfig:"REFRESH_TOKEN_TTL"`
+	// ClockSkew is how far apart the clocks of token issuer and verifier may be
+	ClockSkew int `envconfig:"CLOCK_SKEW"`
+	// RevocationRefresh is how often revocations made by other instances are loaded
+	RevocationRefresh int `envconfig:"REVOCATION_REFRESH"`
+	// AdminName and AdminPassword create the first admin on start, a password already set is left as is
+	AdminName     string `envconfig:"ADMIN_NAME"`
+	AdminPassword string `envconfig:"ADMIN_PASSWORD"`
+}
@@ -7,4 +7,15 @@ type Settings struct {
 	Password       string `envconfig:"PASS"`
 	DBName         int    `envconfig:"DB"`
 	ExpirationTime int    `envconfig:"EXPIRATION_TIME"`
+	// Size is how many banners the memory cache holds in one generation
+	Size int `envconfig:"SIZE"`
+	// LocalExpirationTime is in seconds, it is how long the tiered cache keeps a local copy
+	LocalExpirationTime int    `envconfig:"LOCAL_EXPIRATION_TIME"`
+	Channel             string `envconfig:"CHANNEL"`
+	// KeyPrefix separates environments and services sharing one redis database
+	KeyPrefix  string `envconfig:"KEY_PREFIX"`
+	KeyVersion int    `envconfig:"KEY_VERSION"`
+	// RefreshInterval and RefreshJitter are in seconds
+	RefreshInterval int `envconfig:"REFRESH_INTERVAL"`
+	RefreshJitter   int `envconfig:"REFRESH_JITTER"`
 }
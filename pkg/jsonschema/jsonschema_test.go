@@ -0,0 +1,187 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "boolean schema", schema: `true`},
+		{name: "annotations only", schema: `{"title": "banner", "description": "text", "$comment": "x"}`},
+		{name: "nested properties", schema: `{"type": "object", "properties": {"items": {"type": "array", "items": {"type": "string"}}}}`},
+		{name: "type list", schema: `{"type": ["string", "null"]}`},
+		{name: "invalid json", schema: `{"type":`, wantErr: true},
+		{name: "schema is not an object", schema: `"string"`, wantErr: true},
+		{name: "unknown type", schema: `{"type": "text"}`, wantErr: true},
+		{name: "unsupported keyword", schema: `{"$ref": "#/definitions/a"}`, wantErr: true},
+		{name: "invalid pattern", schema: `{"pattern": "("}`, wantErr: true},
+		{name: "invalid pattern property", schema: `{"patternProperties": {"(": true}}`, wantErr: true},
+		{name: "negative length", schema: `{"minLength": -1}`, wantErr: true},
+		{name: "fractional length", schema: `{"maxItems": 1.5}`, wantErr: true},
+		{name: "zero multiple", schema: `{"multipleOf": 0}`, wantErr: true},
+		{name: "empty allOf", schema: `{"allOf": []}`, wantErr: true},
+		{name: "required is not strings", schema: `{"required": [1]}`, wantErr: true},
+		{name: "invalid nested schema", schema: `{"properties": {"a": {"type": 1}}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  any
+		want   []Violation
+	}{
+		{
+			name:   "valid object",
+			schema: `{"type": "object", "required": ["title"], "properties": {"title": {"type": "string", "minLength": 1}}}`,
+			value:  map[string]any{"title": "some_title"},
+		},
+		{
+			name:   "type mismatch stops other keywords",
+			schema: `{"type": "string", "minLength": 5}`,
+			value:  10,
+			want:   []Violation{{Pointer: "", Message: "expected string, got integer"}},
+		},
+		{
+			name:   "integer is a number",
+			schema: `{"type": "number"}`,
+			value:  3,
+		},
+		{
+			name:   "fraction is not an integer",
+			schema: `{"type": "integer"}`,
+			value:  1.5,
+			want:   []Violation{{Pointer: "", Message: "expected integer, got number"}},
+		},
+		{
+			name:   "missing required property",
+			schema: `{"required": ["title", "url"]}`,
+			value:  map[string]any{"title": "a"},
+			want:   []Violation{{Pointer: "/url", Message: "required property is missing"}},
+		},
+		{
+			name:   "pointer escapes property names",
+			schema: `{"properties": {"a/b~c": {"type": "string"}}}`,
+			value:  map[string]any{"a/b~c": true},
+			want:   []Violation{{Pointer: "/a~1b~0c", Message: "expected string, got boolean"}},
+		},
+		{
+			name:   "additional properties are forbidden",
+			schema: `{"properties": {"title": true}, "additionalProperties": false}`,
+			value:  map[string]any{"title": "a", "url": "b", "text": "c"},
+			want: []Violation{
+				{Pointer: "/text", Message: "additional property is not allowed"},
+				{Pointer: "/url", Message: "additional property is not allowed"},
+			},
+		},
+		{
+			name:   "pattern properties count as known",
+			schema: `{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`,
+			value:  map[string]any{"x-a": "a"},
+		},
+		{
+			name:   "string length counts runes",
+			schema: `{"maxLength": 3}`,
+			value:  "тэг",
+		},
+		{
+			name:   "string pattern",
+			schema: `{"pattern": "^https://"}`,
+			value:  "http://example.com",
+			want:   []Violation{{Pointer: "", Message: `string does not match pattern "^https://"`}},
+		},
+		{
+			name:   "number bounds",
+			schema: `{"minimum": 1, "exclusiveMaximum": 10, "multipleOf": 2}`,
+			value:  10,
+			want: []Violation{
+				{Pointer: "", Message: "number is not less than 10"},
+			},
+		},
+		{
+			name:   "multiple of",
+			schema: `{"multipleOf": 2}`,
+			value:  3,
+			want:   []Violation{{Pointer: "", Message: "number is not a multiple of 2"}},
+		},
+		{
+			name:   "array items and uniqueness",
+			schema: `{"items": {"type": "integer"}, "uniqueItems": true}`,
+			value:  []any{1, "a", 1},
+			want: []Violation{
+				{Pointer: "/2", Message: "array item is not unique"},
+				{Pointer: "/1", Message: "expected integer, got string"},
+			},
+		},
+		{
+			name:   "prefix items take precedence over items",
+			schema: `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`,
+			value:  []any{"a", 1, 2},
+		},
+		{
+			name:   "contains",
+			schema: `{"contains": {"const": "b"}}`,
+			value:  []any{"a", "c"},
+			want:   []Violation{{Pointer: "", Message: "array contains no matching item"}},
+		},
+		{
+			name:   "enum",
+			schema: `{"enum": ["a", 1]}`,
+			value:  "b",
+			want:   []Violation{{Pointer: "", Message: "value is not one of the allowed values"}},
+		},
+		{
+			name:   "oneOf matches both",
+			schema: `{"oneOf": [{"type": "integer"}, {"minimum": 0}]}`,
+			value:  1,
+			want:   []Violation{{Pointer: "", Message: "value matches 2 of oneOf schemas instead of exactly one"}},
+		},
+		{
+			name:   "anyOf matches none",
+			schema: `{"anyOf": [{"type": "string"}, {"type": "boolean"}]}`,
+			value:  1,
+			want:   []Violation{{Pointer: "", Message: "value matches none of anyOf schemas"}},
+		},
+		{
+			name:   "not",
+			schema: `{"not": {"type": "null"}}`,
+			value:  nil,
+			want:   []Violation{{Pointer: "", Message: "value matches a schema it must not match"}},
+		},
+		{
+			name:   "false schema",
+			schema: `{"properties": {"a": false}}`,
+			value:  map[string]any{"a": 1},
+			want:   []Violation{{Pointer: "/a", Message: "value is not allowed"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			got, err := schema.Validate(tt.value)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Schema is a compiled JSON Schema. Only the validation keywords of draft 2020-12
+// that make sense for self-contained schemas are supported, references are not.
+type Schema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+type Violation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+var annotations = map[string]struct{}{
+	"$schema": {}, "$id": {}, "$comment": {}, "title": {}, "description": {},
+	"default": {}, "examples": {}, "format": {}, "deprecated": {}, "readOnly": {}, "writeOnly": {},
+}
+
+func Compile(data []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("can't parse schema: %w", err)
+	}
+	s := &Schema{
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := s.compile(root, ""); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) compile(node any, pointer string) error {
+	if _, ok := node.(bool); ok {
+		return nil
+	}
+	schema, ok := node.(map[string]any)
+	if !ok {
+		return fmt.Errorf("schema at %q must be an object or a boolean", pointer)
+	}
+	for keyword, value := range schema {
+		at := pointer + "/" + escape(keyword)
+		switch keyword {
+		case "type":
+			if err := checkTypes(value, at); err != nil {
+				return err
+			}
+		case "properties", "patternProperties":
+			props, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%q must be an object", at)
+			}
+			for name, sub := range props {
+				if keyword == "patternProperties" {
+					if err := s.compilePattern(name, at); err != nil {
+						return err
+					}
+				}
+				if err := s.compile(sub, at+"/"+escape(name)); err != nil {
+					return err
+				}
+			}
+		case "additionalProperties", "items", "not", "contains", "propertyNames":
+			if err := s.compile(value, at); err != nil {
+				return err
+			}
+		case "allOf", "anyOf", "oneOf", "prefixItems":
+			subs, ok := value.([]any)
+			if !ok || len(subs) == 0 {
+				return fmt.Errorf("%q must be a non-empty array", at)
+			}
+			for i, sub := range subs {
+				if err := s.compile(sub, fmt.Sprintf("%s/%d", at, i)); err != nil {
+					return err
+				}
+			}
+		case "required":
+			names, ok := value.([]any)
+			if !ok {
+				return fmt.Errorf("%q must be an array of strings", at)
+			}
+			for _, name := range names {
+				if _, ok := name.(string); !ok {
+					return fmt.Errorf("%q must be an array of strings", at)
+				}
+			}
+		case "enum":
+			if _, ok := value.([]any); !ok {
+				return fmt.Errorf("%q must be an array", at)
+			}
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%q must be a string", at)
+			}
+			if err := s.compilePattern(pattern, at); err != nil {
+				return err
+			}
+		case "minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties":
+			if n, ok := value.(float64); !ok || n < 0 || n != float64(int(n)) {
+				return fmt.Errorf("%q must be a non-negative integer", at)
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%q must be a number", at)
+			}
+		case "multipleOf":
+			if n, ok := value.(float64); !ok || n <= 0 {
+				return fmt.Errorf("%q must be a positive number", at)
+			}
+		case "uniqueItems":
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("%q must be a boolean", at)
+			}
+		case "const":
+		default:
+			if _, ok := annotations[keyword]; !ok {
+				return fmt.Errorf("unsupported keyword %q", at)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compilePattern(pattern, pointer string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("%q has invalid pattern: %w", pointer, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+func checkTypes(value any, pointer string) error {
+	var types []any
+	switch v := value.(type) {
+	case string:
+		types = []any{v}
+	case []any:
+		types = v
+	default:
+		return fmt.Errorf("%q must be a string or an array of strings", pointer)
+	}
+	for _, t := range types {
+		name, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("%q must be a string or an array of strings", pointer)
+		}
+		switch name {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return fmt.Errorf("%q has unknown type %q", pointer, name)
+		}
+	}
+	return nil
+}
+
+// escape encodes a reference token as described in RFC 6901.
+func escape(token string) string {
+	out := make([]byte, 0, len(token))
+	for i := 0; i < len(token); i++ {
+		switch token[i] {
+		case '~':
+			out = append(out, '~', '0')
+		case '/':
+			out = append(out, '~', '1')
+		default:
+			out = append(out, token[i])
+		}
+	}
+	return string(out)
+}
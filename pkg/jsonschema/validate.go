@@ -0,0 +1,251 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks value against the schema and returns every violation found.
+// The value is passed through encoding/json first, so any marshalable type is accepted.
+func (s *Schema) Validate(value any) ([]Violation, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal value: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("can't unmarshal value: %w", err)
+	}
+	var violations []Violation
+	s.validate(s.root, doc, "", &violations)
+	return violations, nil
+}
+
+func (s *Schema) validate(node, value any, pointer string, out *[]Violation) {
+	if allowed, ok := node.(bool); ok {
+		if !allowed {
+			*out = append(*out, Violation{Pointer: pointer, Message: "value is not allowed"})
+		}
+		return
+	}
+	schema := node.(map[string]any)
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("expected %s, got %s", typeList(t), typeOf(value))})
+		// the remaining keywords would only repeat the type mismatch
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+		*out = append(*out, Violation{Pointer: pointer, Message: "value is not one of the allowed values"})
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		*out = append(*out, Violation{Pointer: pointer, Message: "value does not match the constant"})
+	}
+
+	switch v := value.(type) {
+	case string:
+		s.validateString(schema, v, pointer, out)
+	case float64:
+		validateNumber(schema, v, pointer, out)
+	case map[string]any:
+		s.validateObject(schema, v, pointer, out)
+	case []any:
+		s.validateArray(schema, v, pointer, out)
+	}
+
+	if subs, ok := schema["allOf"].([]any); ok {
+		for _, sub := range subs {
+			s.validate(sub, value, pointer, out)
+		}
+	}
+	if subs, ok := schema["anyOf"].([]any); ok {
+		if s.countMatches(subs, value, pointer) == 0 {
+			*out = append(*out, Violation{Pointer: pointer, Message: "value matches none of anyOf schemas"})
+		}
+	}
+	if subs, ok := schema["oneOf"].([]any); ok {
+		if n := s.countMatches(subs, value, pointer); n != 1 {
+			*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("value matches %d of oneOf schemas instead of exactly one", n)})
+		}
+	}
+	if sub, ok := schema["not"]; ok && s.matches(sub, value, pointer) {
+		*out = append(*out, Violation{Pointer: pointer, Message: "value matches a schema it must not match"})
+	}
+}
+
+func (s *Schema) matches(node, value any, pointer string) bool {
+	var violations []Violation
+	s.validate(node, value, pointer, &violations)
+	return len(violations) == 0
+}
+
+func (s *Schema) countMatches(nodes []any, value any, pointer string) int {
+	n := 0
+	for _, node := range nodes {
+		if s.matches(node, value, pointer) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Schema) validateString(schema map[string]any, value, pointer string, out *[]Violation) {
+	length := utf8.RuneCountInString(value)
+	if n, ok := schema["minLength"].(float64); ok && float64(length) < n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("string is shorter than %d characters", int(n))})
+	}
+	if n, ok := schema["maxLength"].(float64); ok && float64(length) > n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("string is longer than %d characters", int(n))})
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !s.patterns[pattern].MatchString(value) {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("string does not match pattern %q", pattern)})
+	}
+}
+
+func validateNumber(schema map[string]any, value float64, pointer string, out *[]Violation) {
+	if n, ok := schema["minimum"].(float64); ok && value < n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("number is less than %v", n)})
+	}
+	if n, ok := schema["maximum"].(float64); ok && value > n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("number is greater than %v", n)})
+	}
+	if n, ok := schema["exclusiveMinimum"].(float64); ok && value <= n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("number is not greater than %v", n)})
+	}
+	if n, ok := schema["exclusiveMaximum"].(float64); ok && value >= n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("number is not less than %v", n)})
+	}
+	if n, ok := schema["multipleOf"].(float64); ok {
+		if q := value / n; q != math.Trunc(q) {
+			*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("number is not a multiple of %v", n)})
+		}
+	}
+}
+
+func (s *Schema) validateObject(schema map[string]any, value map[string]any, pointer string, out *[]Violation) {
+	if n, ok := schema["minProperties"].(float64); ok && float64(len(value)) < n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("object has fewer than %d properties", int(n))})
+	}
+	if n, ok := schema["maxProperties"].(float64); ok && float64(len(value)) > n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("object has more than %d properties", int(n))})
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				*out = append(*out, Violation{Pointer: pointer + "/" + escape(name.(string)), Message: "required property is missing"})
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	patternProperties, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	propertyNames, hasPropertyNames := schema["propertyNames"]
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		at := pointer + "/" + escape(name)
+		if hasPropertyNames && !s.matches(propertyNames, name, at) {
+			*out = append(*out, Violation{Pointer: at, Message: "property name is not allowed"})
+		}
+		known := false
+		if sub, ok := properties[name]; ok {
+			known = true
+			s.validate(sub, value[name], at, out)
+		}
+		for pattern, sub := range patternProperties {
+			if s.patterns[pattern].MatchString(name) {
+				known = true
+				s.validate(sub, value[name], at, out)
+			}
+		}
+		if !known && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				*out = append(*out, Violation{Pointer: at, Message: "additional property is not allowed"})
+			} else {
+				s.validate(additional, value[name], at, out)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateArray(schema map[string]any, value []any, pointer string, out *[]Violation) {
+	if n, ok := schema["minItems"].(float64); ok && float64(len(value)) < n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("array has fewer than %d items", int(n))})
+	}
+	if n, ok := schema["maxItems"].(float64); ok && float64(len(value)) > n {
+		*out = append(*out, Violation{Pointer: pointer, Message: fmt.Sprintf("array has more than %d items", int(n))})
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range value {
+			if slices.ContainsFunc(value[:i], func(v any) bool { return reflect.DeepEqual(v, value[i]) }) {
+				*out = append(*out, Violation{Pointer: fmt.Sprintf("%s/%d", pointer, i), Message: "array item is not unique"})
+			}
+		}
+	}
+
+	prefix, _ := schema["prefixItems"].([]any)
+	for i, item := range value {
+		at := fmt.Sprintf("%s/%d", pointer, i)
+		if i < len(prefix) {
+			s.validate(prefix[i], item, at, out)
+		} else if sub, ok := schema["items"]; ok {
+			s.validate(sub, item, at, out)
+		}
+	}
+	if sub, ok := schema["contains"]; ok && !slices.ContainsFunc(value, func(v any) bool { return s.matches(sub, v, pointer) }) {
+		*out = append(*out, Violation{Pointer: pointer, Message: "array contains no matching item"})
+	}
+}
+
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesType(types, value any) bool {
+	actual := typeOf(value)
+	check := func(t any) bool {
+		return t == actual || (t == "number" && actual == "integer")
+	}
+	if list, ok := types.([]any); ok {
+		return slices.ContainsFunc(list, check)
+	}
+	return check(types)
+}
+
+func typeList(types any) string {
+	if list, ok := types.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, t := range list {
+			names = append(names, t.(string))
+		}
+		return strings.Join(names, " or ")
+	}
+	return types.(string)
+}
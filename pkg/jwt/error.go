@@ -4,5 +4,9 @@ import "errors"
 
 var (
 	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token is expired")
 	ErrUnexpectedMethod = errors.New("unexpected method")
+	ErrUnsupportedKey   = errors.New("unsupported key")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrNoKeys           = errors.New("no signing keys")
 )
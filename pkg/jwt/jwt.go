@@ -1,14 +1,24 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Service struct {
-	method  *jwt.SigningMethodHMAC
-	signKey []byte
+	// method and keys set by the options make a keyring of a single key, unless WithKeyring is given
+	method  jwt.SigningMethod
+	signKey any
+	// verifyKey is the public key for asymmetric methods and the sign key itself for HMAC
+	verifyKey any
+	// jwk is set for asymmetric methods only, a shared secret is never published
+	jwk     *JWK
+	keyring *Keyring
+	// leeway is the clock skew tolerated when checking exp, nbf and iat
+	leeway time.Duration
 }
 
 type JWTOption func(s *Service)
@@ -23,6 +33,16 @@ func NewJwtService(signKey []byte, opts ...JWTOption) Service {
 	if s.method == nil {
 		WithMethodHS256(&s)
 	}
+	if s.verifyKey == nil {
+		s.verifyKey = s.signKey
+	}
+	if s.keyring == nil {
+		key := Key{method: s.method, sign: s.signKey, verify: s.verifyKey, jwk: s.jwk}
+		if s.jwk != nil {
+			key.ID = s.jwk.Kid
+		}
+		s.keyring = staticKeyring(key)
+	}
 
 	return s
 }
@@ -30,13 +50,21 @@ func NewJwtService(signKey []byte, opts ...JWTOption) Service {
 func (js Service) Parse(token string) (map[string]any, error) {
 	claims := jwt.MapClaims{}
 	jwtToken, err := jwt.ParseWithClaims(token, &claims, func(jwtToken *jwt.Token) (interface{}, error) {
-
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", jwtToken.Header["alg"])
+		kid, _ := jwtToken.Header["kid"].(string)
+		key, ok := js.keyring.lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+		}
+		// a token must never choose how it is verified, otherwise a public key could be used as an HMAC secret
+		if jwtToken.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("%w: %v", ErrUnexpectedMethod, jwtToken.Header["alg"])
 		}
-		return js.signKey, nil
-	})
+		return key.verify, nil
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt(), jwt.WithLeeway(js.leeway))
 	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
 		return nil, fmt.Errorf("can't parse jwt token: %w", err)
 	}
 
@@ -59,11 +87,21 @@ func (js Service) NewToken(fields map[string]any) (string, error) {
 	for k, v := range fields {
 		fmap[k] = v
 	}
-	token := jwt.NewWithClaims(js.method, fmap)
+	key := js.keyring.active()
+	token := jwt.NewWithClaims(key.method, fmap)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 
-	s, err := token.SignedString(js.signKey)
+	s, err := token.SignedString(key.sign)
 	if err != nil {
-		return "", fmt.Errorf("can't sign token with key %s: %w", js.signKey, err)
+		return "", fmt.Errorf("can't sign token with key %q: %w", key.ID, err)
 	}
 	return s, nil
 }
+
+// JWKS returns the public keys tokens can be verified with, active and retired ones.
+// HMAC secrets are never published.
+func (js Service) JWKS() JWKSet {
+	return js.keyring.jwks()
+}
@@ -0,0 +1,218 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"name": "user",
+		"iat":  now.Unix(),
+		"exp":  now.Add(time.Hour).Unix(),
+	}
+}
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("can't generate rsa key: %v", err)
+	}
+	return key
+}
+
+func mustECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("can't generate ec key: %v", err)
+	}
+	return key
+}
+
+func mustEdKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("can't generate ed25519 key: %v", err)
+	}
+	return key
+}
+
+func TestParseMethodCheck(t *testing.T) {
+	rsaKey := mustRSAKey(t)
+	es256, err := WithMethodES256(mustECKey(t, elliptic.P256()))
+	if err != nil {
+		t.Fatalf("WithMethodES256() error = %v", err)
+	}
+	edKey := mustEdKey(t)
+	publicPEM := func() []byte {
+		der, err := x509.MarshalPKIXPublicKey(rsaKey.Public())
+		if err != nil {
+			t.Fatalf("can't marshal public key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}()
+
+	tests := []struct {
+		name     string
+		sign     func() (string, error)
+		verifier Service
+		wantErr  error
+	}{
+		{
+			name:     "HS256",
+			sign:     func() (string, error) { return NewJwtService([]byte("secret")).NewToken(testClaims()) },
+			verifier: NewJwtService([]byte("secret")),
+		},
+		{
+			name:     "RS256",
+			sign:     func() (string, error) { return NewJwtService(nil, WithMethodRS256(rsaKey)).NewToken(testClaims()) },
+			verifier: NewJwtService(nil, WithMethodRS256(rsaKey)),
+		},
+		{
+			name:     "ES256",
+			sign:     func() (string, error) { return NewJwtService(nil, es256).NewToken(testClaims()) },
+			verifier: NewJwtService(nil, es256),
+		},
+		{
+			name:     "EdDSA",
+			sign:     func() (string, error) { return NewJwtService(nil, WithMethodEdDSA(edKey)).NewToken(testClaims()) },
+			verifier: NewJwtService(nil, WithMethodEdDSA(edKey)),
+		},
+		{
+			name:     "wrong secret",
+			sign:     func() (string, error) { return NewJwtService([]byte("other")).NewToken(testClaims()) },
+			verifier: NewJwtService([]byte("secret")),
+			wantErr:  jwt.ErrSignatureInvalid,
+		},
+		{
+			name:     "HS512 token for HS256 service",
+			sign:     func() (string, error) { return NewJwtService([]byte("secret"), WithMethodHS512).NewToken(testClaims()) },
+			verifier: NewJwtService([]byte("secret")),
+			wantErr:  ErrUnexpectedMethod,
+		},
+		{
+			name: "HMAC token signed with the published public key",
+			sign: func() (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(testClaims())).SignedString(publicPEM)
+			},
+			verifier: NewJwtService(nil, WithMethodRS256(rsaKey)),
+			wantErr:  ErrUnexpectedMethod,
+		},
+		{
+			name: "unsigned token",
+			sign: func() (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims(testClaims())).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			},
+			verifier: NewJwtService([]byte("secret")),
+			wantErr:  ErrUnexpectedMethod,
+		},
+		{
+			name: "expired token",
+			sign: func() (string, error) {
+				claims := testClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return NewJwtService([]byte("secret")).NewToken(claims)
+			},
+			verifier: NewJwtService([]byte("secret")),
+			wantErr:  ErrTokenExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.sign()
+			if err != nil {
+				t.Fatalf("can't sign token: %v", err)
+			}
+			claims, err := tt.verifier.Parse(token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims["name"] != "user" {
+				t.Errorf("Parse() name = %v, want user", claims["name"])
+			}
+		})
+	}
+}
+
+func TestWithPrivateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		wantAlg string
+		wantKty string
+		wantErr error
+	}{
+		{name: "rsa", key: mustRSAKey(t), wantAlg: "RS256", wantKty: "RSA"},
+		{name: "ec P-256", key: mustECKey(t, elliptic.P256()), wantAlg: "ES256", wantKty: "EC"},
+		{name: "ed25519", key: mustEdKey(t), wantAlg: "EdDSA", wantKty: "OKP"},
+		{name: "ec P-384", key: mustECKey(t, elliptic.P384()), wantErr: ErrUnsupportedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt, err := WithPrivateKey(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithPrivateKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			s := NewJwtService(nil, opt)
+			token, err := s.NewToken(testClaims())
+			if err != nil {
+				t.Fatalf("NewToken() error = %v", err)
+			}
+			if _, err := s.Parse(token); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			jwks := s.JWKS()
+			if len(jwks.Keys) != 1 {
+				t.Fatalf("JWKS() has %d keys, want 1", len(jwks.Keys))
+			}
+			if key := jwks.Keys[0]; key.Alg != tt.wantAlg || key.Kty != tt.wantKty || key.Kid == "" {
+				t.Errorf("JWKS() key = %+v, want alg %s, kty %s and a kid", key, tt.wantAlg, tt.wantKty)
+			}
+		})
+	}
+}
+
+func TestWithMethodES256(t *testing.T) {
+	tests := []struct {
+		name    string
+		curve   elliptic.Curve
+		wantErr error
+	}{
+		{name: "P-256", curve: elliptic.P256()},
+		{name: "P-384", curve: elliptic.P384(), wantErr: ErrUnsupportedKey},
+		{name: "P-521", curve: elliptic.P521(), wantErr: ErrUnsupportedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := WithMethodES256(mustECKey(t, tt.curve)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithMethodES256() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWKSHidesSecrets(t *testing.T) {
+	for _, opt := range []JWTOption{WithMethodHS256, WithMethodHS512} {
+		if jwks := NewJwtService([]byte("secret"), opt).JWKS(); len(jwks.Keys) != 0 {
+			t.Errorf("JWKS() = %+v, want no keys", jwks)
+		}
+	}
+}
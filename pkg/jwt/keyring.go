@@ -0,0 +1,240 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const activeKeyFile = "active"
+
+// Key is a signing key with its id, the id goes to the kid header of every token signed with it.
+type Key struct {
+	ID     string
+	method jwt.SigningMethod
+	sign   any
+	verify any
+	jwk    *JWK
+}
+
+func NewSecretKey(id string, secret []byte) Key {
+	return Key{
+		ID:     id,
+		method: jwt.SigningMethodHS256,
+		sign:   secret,
+		verify: secret,
+	}
+}
+
+// NewPrivateKey picks the method by the type of the key: RS256, ES256 or EdDSA.
+func NewPrivateKey(id string, key crypto.Signer) (Key, error) {
+	method, err := methodFor(key)
+	if err != nil {
+		return Key{}, err
+	}
+	jwk, err := publicJWK(key.Public(), method.Alg())
+	if err != nil {
+		return Key{}, err
+	}
+	jwk.Kid = id
+	return Key{
+		ID:     id,
+		method: method,
+		sign:   key,
+		verify: key.Public(),
+		jwk:    &jwk,
+	}, nil
+}
+
+// ParseKey reads a PEM encoded private key, anything else is taken as an HMAC secret.
+func ParseKey(id string, data []byte) (Key, error) {
+	if block, _ := pem.Decode(data); block == nil {
+		return NewSecretKey(id, data), nil
+	}
+	private, err := ParsePrivateKey(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("can't parse key %s: %w", id, err)
+	}
+	return NewPrivateKey(id, private)
+}
+
+// KeySource returns all known keys and the id of the active one.
+type KeySource func() ([]Key, string, error)
+
+// DirKeySource reads keys from the directory: <id>.pem holds a private key, <id>.secret an HMAC secret,
+// and the file named active holds the id of the key new tokens are signed with.
+func DirKeySource(dir string) KeySource {
+	return func() ([]Key, string, error) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("can't read keys directory: %w", err)
+		}
+		var keys []Key
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			ext := filepath.Ext(entry.Name())
+			if ext != ".pem" && ext != ".secret" {
+				continue
+			}
+			id := strings.TrimSuffix(entry.Name(), ext)
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, "", fmt.Errorf("can't read key %s: %w", id, err)
+			}
+			var key Key
+			if ext == ".secret" {
+				// files written with echo end with a newline that is not a part of the secret
+				key = NewSecretKey(id, bytes.TrimSpace(data))
+			} else if key, err = ParseKey(id, data); err != nil {
+				return nil, "", err
+			}
+			keys = append(keys, key)
+		}
+
+		active, err := os.ReadFile(filepath.Join(dir, activeKeyFile))
+		if err != nil {
+			return nil, "", fmt.Errorf("can't read active key id: %w", err)
+		}
+		return keys, string(bytes.TrimSpace(active)), nil
+	}
+}
+
+// EnvKeySource reads keys from variables named prefix followed by the key id, their values are
+// PEM encoded private keys or HMAC secrets. The id of the active key is in the variable active.
+// The environment of a process is fixed, so reloading such a keyring brings nothing new.
+func EnvKeySource(prefix, active string) KeySource {
+	return func() ([]Key, string, error) {
+		var keys []Key
+		for _, env := range os.Environ() {
+			name, value, _ := strings.Cut(env, "=")
+			if !strings.HasPrefix(name, prefix) || name == active {
+				continue
+			}
+			key, err := ParseKey(strings.ToLower(strings.TrimPrefix(name, prefix)), []byte(value))
+			if err != nil {
+				return nil, "", err
+			}
+			keys = append(keys, key)
+		}
+		return keys, strings.ToLower(os.Getenv(active)), nil
+	}
+}
+
+type keySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+func newKeySet(keys []Key, active string) (keySet, error) {
+	if len(keys) == 0 {
+		return keySet{}, ErrNoKeys
+	}
+	set := keySet{keys: make(map[string]*Key, len(keys))}
+	for i := range keys {
+		if _, ok := set.keys[keys[i].ID]; ok {
+			return keySet{}, fmt.Errorf("duplicate key id %q", keys[i].ID)
+		}
+		set.keys[keys[i].ID] = &keys[i]
+	}
+	var ok bool
+	if set.active, ok = set.keys[active]; !ok {
+		return keySet{}, fmt.Errorf("%w: active key %q", ErrUnknownKey, active)
+	}
+	return set, nil
+}
+
+// Keyring holds one active key new tokens are signed with and retired keys
+// that are still accepted, so keys can be rotated without invalidating issued tokens.
+type Keyring struct {
+	source KeySource
+	mu     sync.RWMutex
+	set    keySet
+}
+
+func NewKeyring(source KeySource) (*Keyring, error) {
+	k := &Keyring{source: source}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func staticKeyring(key Key) *Keyring {
+	set, _ := newKeySet([]Key{key}, key.ID)
+	return &Keyring{set: set}
+}
+
+// Reload reads the keys from the source again. On failure the keys loaded before stay in use.
+func (k *Keyring) Reload() error {
+	if k.source == nil {
+		return nil
+	}
+	keys, active, err := k.source()
+	if err != nil {
+		return fmt.Errorf("can't load keys: %w", err)
+	}
+	set, err := newKeySet(keys, active)
+	if err != nil {
+		return fmt.Errorf("can't load keys: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.set = set
+	return nil
+}
+
+func (k *Keyring) ActiveID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.set.active.ID
+}
+
+func (k *Keyring) Size() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.set.keys)
+}
+
+func (k *Keyring) active() Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return *k.set.active
+}
+
+// lookup finds the key by the kid header, tokens without one are checked with the active key.
+func (k *Keyring) lookup(kid string) (Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if kid == "" {
+		return *k.set.active, true
+	}
+	key, ok := k.set.keys[kid]
+	if !ok {
+		return Key{}, false
+	}
+	return *key, true
+}
+
+func (k *Keyring) jwks() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.set.keys {
+		if key.jwk != nil {
+			set.Keys = append(set.Keys, *key.jwk)
+		}
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
@@ -1,6 +1,16 @@
 package jwt
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 func WithMethodHS256(s *Service) {
 	s.method = jwt.SigningMethodHS256
@@ -9,3 +19,72 @@ func WithMethodHS256(s *Service) {
 func WithMethodHS512(s *Service) {
 	s.method = jwt.SigningMethodHS512
 }
+
+// WithMethodRS256 signs tokens with the RSA key, the verification needs only its public part.
+func WithMethodRS256(key *rsa.PrivateKey) JWTOption {
+	return withKeyPair(jwt.SigningMethodRS256, key, key.Public())
+}
+
+// WithMethodES256 signs tokens with the ECDSA key, which must be on the P-256 curve:
+// a key on another curve would sign tokens labelled ES256 that never verify.
+func WithMethodES256(key *ecdsa.PrivateKey) (JWTOption, error) {
+	if _, err := methodFor(key); err != nil {
+		return nil, err
+	}
+	return withKeyPair(jwt.SigningMethodES256, key, key.Public()), nil
+}
+
+func WithMethodEdDSA(key ed25519.PrivateKey) JWTOption {
+	return withKeyPair(jwt.SigningMethodEdDSA, key, key.Public())
+}
+
+// WithPrivateKey picks the method by the type of the key: RS256, ES256 or EdDSA.
+func WithPrivateKey(key crypto.Signer) (JWTOption, error) {
+	method, err := methodFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return withKeyPair(method, key, key.Public()), nil
+}
+
+// WithKeyring signs with the active key of the keyring and verifies with the key named by the kid header.
+// It takes precedence over the key and the method given by other options.
+func WithKeyring(k *Keyring) JWTOption {
+	return func(s *Service) {
+		s.keyring = k
+	}
+}
+
+func methodFor(key crypto.Signer) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%w: ES256 needs a P-256 key, got %s", ErrUnsupportedKey, k.Curve.Params().Name)
+		}
+		return jwt.SigningMethodES256, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+}
+
+func withKeyPair(method jwt.SigningMethod, private crypto.Signer, public crypto.PublicKey) JWTOption {
+	return func(s *Service) {
+		s.method = method
+		s.signKey = private
+		s.verifyKey = public
+		// publicJWK fails only for key types the options above don't accept
+		if jwk, err := publicJWK(public, method.Alg()); err == nil {
+			s.jwk = &jwk
+		}
+	}
+}
+
+// WithLeeway tolerates clocks of the issuer and the verifier being apart by up to d.
+func WithLeeway(d time.Duration) JWTOption {
+	return func(s *Service) {
+		s.leeway = d
+	}
+}